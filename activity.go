@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const defaultActivityWeeks = 8
+
+// idleThreshold is the gap between entries after which a session is
+// considered idle rather than worked on
+var idleThreshold = 15 * time.Minute
+
+// WorkPeriod is a stretch of a session without idle gaps
+type WorkPeriod struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Duration returns the length of the work period
+func (p WorkPeriod) Duration() time.Duration {
+	return p.End.Sub(p.Start)
+}
+
+// SessionActivity describes when a session was worked on
+type SessionActivity struct {
+	Start   time.Time
+	End     time.Time
+	Active  time.Duration
+	Periods []WorkPeriod
+}
+
+// WallClock returns the time from the first entry to the last
+func (a SessionActivity) WallClock() time.Duration {
+	return a.End.Sub(a.Start)
+}
+
+// Idle returns the time spent in gaps longer than the idle threshold
+func (a SessionActivity) Idle() time.Duration {
+	return a.WallClock() - a.Active
+}
+
+// sessionActivity splits entry timestamps into work periods wherever two
+// consecutive entries are further apart than the idle threshold
+func sessionActivity(timestamps []time.Time) SessionActivity {
+	var activity SessionActivity
+
+	sorted := make([]time.Time, 0, len(timestamps))
+	for _, t := range timestamps {
+		if !t.IsZero() {
+			sorted = append(sorted, t)
+		}
+	}
+	if len(sorted) == 0 {
+		return activity
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+	activity.Start = sorted[0]
+	activity.End = sorted[len(sorted)-1]
+
+	period := WorkPeriod{Start: sorted[0], End: sorted[0]}
+	for _, t := range sorted[1:] {
+		if t.Sub(period.End) > idleThreshold {
+			activity.Periods = append(activity.Periods, period)
+			period = WorkPeriod{Start: t, End: t}
+			continue
+		}
+		period.End = t
+	}
+	activity.Periods = append(activity.Periods, period)
+
+	for _, p := range activity.Periods {
+		activity.Active += p.Duration()
+	}
+	return activity
+}
+
+// entriesActivity computes the activity of a parsed session
+func entriesActivity(entries []LogEntry) SessionActivity {
+	timestamps := make([]time.Time, 0, len(entries))
+	for _, entry := range entries {
+		timestamps = append(timestamps, entry.Timestamp)
+	}
+	return sessionActivity(timestamps)
+}
+
+// projectActiveTime sums the active time of a project's sessions
+func projectActiveTime(project ProjectInfo) time.Duration {
+	var total time.Duration
+	for _, session := range project.Sessions {
+		total += session.Activity.Active
+	}
+	return total
+}
+
+// ProjectWeeklyHours is the active time of one project in each week
+type ProjectWeeklyHours struct {
+	Project ProjectInfo
+	Weeks   []time.Duration
+	Total   time.Duration
+}
+
+// WeeklyActivity is active agent time per project per week
+type WeeklyActivity struct {
+	Weeks    []time.Time
+	Projects []ProjectWeeklyHours
+	Totals   []time.Duration
+	Total    time.Duration
+}
+
+// getWeeklyActivity aggregates the work periods of every session into
+// hours per project per week for the last n weeks
+func getWeeklyActivity(ctx context.Context, weeks int, now time.Time) (WeeklyActivity, error) {
+	sessions := listAllSessions()
+	err := forEachParallel(ctx, scanWorkers, len(sessions), func(i int) {
+		sessions[i].Session.Activity = sessionActivity(readSessionTimestamps(sessions[i].Path))
+	})
+	if err != nil {
+		return WeeklyActivity{}, err
+	}
+	return buildWeeklyActivity(sessions, weeks, now), nil
+}
+
+func buildWeeklyActivity(sessions []LoadedSession, weeks int, now time.Time) WeeklyActivity {
+	activity := WeeklyActivity{Totals: make([]time.Duration, weeks)}
+	first := startOfWeek(now).AddDate(0, 0, -7*(weeks-1))
+	index := make(map[time.Time]int)
+	for i := 0; i < weeks; i++ {
+		week := first.AddDate(0, 0, 7*i)
+		index[week] = i
+		activity.Weeks = append(activity.Weeks, week)
+	}
+
+	projects := make(map[string]*ProjectWeeklyHours)
+	var order []string
+	for _, session := range sessions {
+		for _, period := range session.Session.Activity.Periods {
+			// Periods spanning midnight on Sunday are split between weeks
+			for start := period.Start.In(now.Location()); start.Before(period.End); {
+				weekStart := startOfWeek(start)
+				end := weekStart.AddDate(0, 0, 7)
+				if period.End.Before(end) {
+					end = period.End
+				}
+				week, ok := index[weekStart]
+				if !ok {
+					start = end
+					continue
+				}
+
+				key := session.Project.Root.ID + "/" + session.Project.Name
+				row := projects[key]
+				if row == nil {
+					row = &ProjectWeeklyHours{Project: session.Project, Weeks: make([]time.Duration, weeks)}
+					projects[key] = row
+					order = append(order, key)
+				}
+				d := end.Sub(start)
+				row.Weeks[week] += d
+				row.Total += d
+				activity.Totals[week] += d
+				activity.Total += d
+				start = end
+			}
+		}
+	}
+
+	for _, key := range order {
+		activity.Projects = append(activity.Projects, *projects[key])
+	}
+	sort.SliceStable(activity.Projects, func(i, j int) bool {
+		return activity.Projects[i].Total > activity.Projects[j].Total
+	})
+	return activity
+}
+
+// activityChart draws the active hours of each week
+func activityChart(activity WeeklyActivity) BarChart {
+	labels := make([]string, len(activity.Weeks))
+	values := make([][]float64, len(activity.Weeks))
+	for i, week := range activity.Weeks {
+		labels[i] = "Wk " + week.Format("Jan 2")
+		values[i] = []float64{activity.Totals[i].Hours()}
+	}
+	return newBarChart("Active hours per week", []ChartSeries{{Name: "Hours", Color: "#0ea5e9"}}, labels, values, formatChartHours)
+}
+
+func formatChartHours(value float64) string {
+	return fmt.Sprintf("%.1fh", value)
+}
+
+// formatClock formats a time of day with its date, e.g. Jan 2 15:04
+func formatClock(t time.Time) string {
+	return t.Local().Format("Jan 2 15:04")
+}
+
+// formatHours formats a duration as hours with one decimal, blank for zero
+func formatHours(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.1fh", d.Hours())
+}
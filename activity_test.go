@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuildWeeklyActivity(t *testing.T) {
+	// Clocks in New York went forward on Sunday, March 8 2026, so the week
+	// starting Monday, March 2 was an hour short
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 3, day, hour, minute, 0, 0, newYork) }
+	now := at(18, 12, 0)
+	wantWeeks := []time.Time{at(-5, 0, 0), at(2, 0, 0), at(9, 0, 0), at(16, 0, 0)}
+
+	tests := []struct {
+		name  string
+		start time.Time
+		end   time.Time
+		// want is the active time in each of the four weeks shown
+		want []time.Duration
+	}{
+		{"week before the change", at(4, 10, 0), at(4, 11, 0), []time.Duration{0, time.Hour, 0, 0}},
+		{"week after the change", at(11, 10, 0), at(11, 11, 0), []time.Duration{0, 0, time.Hour, 0}},
+		{"current week", at(17, 9, 0), at(17, 9, 30), []time.Duration{0, 0, 0, 30 * time.Minute}},
+		{"split at midnight on Sunday", at(8, 23, 0), at(9, 1, 0), []time.Duration{0, time.Hour, time.Hour, 0}},
+		{"first week shown", at(-4, 10, 0), at(-4, 12, 0), []time.Duration{2 * time.Hour, 0, 0, 0}},
+		{"before the weeks shown", at(-10, 10, 0), at(-10, 11, 0), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := LoadedSession{
+				Project: ProjectInfo{Name: "-src-app", DisplayName: "app"},
+				Session: SessionInfo{Activity: SessionActivity{Periods: []WorkPeriod{{Start: tt.start.UTC(), End: tt.end.UTC()}}}},
+			}
+			activity := buildWeeklyActivity([]LoadedSession{session}, len(wantWeeks), now)
+
+			for i, week := range activity.Weeks {
+				if !week.Equal(wantWeeks[i]) {
+					t.Errorf("week %d starts %s, want %s", i, week, wantWeeks[i])
+				}
+			}
+			if tt.want == nil {
+				if len(activity.Projects) != 0 || activity.Total != 0 {
+					t.Errorf("got %v active in %d projects, want nothing", activity.Total, len(activity.Projects))
+				}
+				return
+			}
+			if !reflect.DeepEqual(activity.Totals, tt.want) {
+				t.Errorf("weekly totals = %v, want %v", activity.Totals, tt.want)
+			}
+			if len(activity.Projects) != 1 {
+				t.Fatalf("got %d project rows, want 1", len(activity.Projects))
+			}
+			if got := activity.Projects[0].Weeks; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("project weeks = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxBashHistoryRows = 500
+
+var exitCodePattern = regexp.MustCompile(`(?i)exit code:?\s*(\d+)`)
+
+// BashRecord is one Bash command run by the agent
+type BashRecord struct {
+	Project     ProjectInfo
+	Session     SessionInfo
+	Call        ToolCall
+	Command     string
+	Description string
+	Timestamp   time.Time
+	ExitCode    *int
+}
+
+// BashCommandCount aggregates the runs of an identical command
+type BashCommandCount struct {
+	Command string
+	Count   int
+	Errors  int
+	LastRun time.Time
+}
+
+// Status describes how the command finished: ok, error or pending when no
+// result was recorded
+func (r BashRecord) Status() string {
+	switch {
+	case r.Call.Result == nil:
+		return "pending"
+	case r.Call.IsError() || (r.ExitCode != nil && *r.ExitCode != 0):
+		return "error"
+	}
+	return "ok"
+}
+
+// getBashHistory returns every Bash command across all sessions, newest first
+func getBashHistory(ctx context.Context) ([]BashRecord, error) {
+	sessions, err := loadAllSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []BashRecord
+	for _, session := range sessions {
+		for _, call := range pairToolCalls(session.Entries) {
+			input, ok := call.Use.Input.(BashInput)
+			if !ok {
+				continue
+			}
+			records = append(records, BashRecord{
+				Project:     session.Project,
+				Session:     session.Session,
+				Call:        call,
+				Command:     input.Command,
+				Description: input.Description,
+				Timestamp:   call.UseTime,
+				ExitCode:    parseExitCode(call.ResultText()),
+			})
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.After(records[j].Timestamp)
+	})
+	return records, nil
+}
+
+func parseExitCode(output string) *int {
+	match := exitCodePattern.FindStringSubmatch(output)
+	if match == nil {
+		return nil
+	}
+	code, err := strconv.Atoi(match[1])
+	if err != nil {
+		return nil
+	}
+	return &code
+}
+
+// filterBashHistory keeps the records whose command, description or project
+// contains the filter text, ignoring case
+func filterBashHistory(records []BashRecord, filter string) []BashRecord {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+	if filter == "" {
+		return records
+	}
+
+	var filtered []BashRecord
+	for _, record := range records {
+		if strings.Contains(strings.ToLower(record.Command), filter) ||
+			strings.Contains(strings.ToLower(record.Description), filter) ||
+			strings.Contains(strings.ToLower(record.Project.DisplayName), filter) {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered
+}
+
+// bashCommandFrequencies counts how often each command was run, most
+// frequent first
+func bashCommandFrequencies(records []BashRecord) []BashCommandCount {
+	var counts []BashCommandCount
+	index := make(map[string]int)
+
+	for _, record := range records {
+		command := strings.TrimSpace(record.Command)
+		i, ok := index[command]
+		if !ok {
+			i = len(counts)
+			index[command] = i
+			counts = append(counts, BashCommandCount{Command: command})
+		}
+		counts[i].Count++
+		if record.Status() == "error" {
+			counts[i].Errors++
+		}
+		if record.Timestamp.After(counts[i].LastRun) {
+			counts[i].LastRun = record.Timestamp
+		}
+	}
+
+	sort.SliceStable(counts, func(i, j int) bool {
+		return counts[i].Count > counts[j].Count
+	})
+	return counts
+}
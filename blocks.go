@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// blockDuration is the length of a Claude plan usage window
+const blockDuration = 5 * time.Hour
+
+// PlanLimit is the usage allowed in one 5-hour block. Zero values mean the
+// limit is unknown.
+type PlanLimit struct {
+	Tokens int     `json:"tokens"`
+	Cost   float64 `json:"cost"`
+}
+
+// planLimit is set from the config file
+var planLimit PlanLimit
+
+// UsageBlock is a 5-hour usage window. A block starts at the hour of the
+// first response sent outside the previous block.
+type UsageBlock struct {
+	Start         time.Time
+	End           time.Time
+	FirstActivity time.Time
+	LastActivity  time.Time
+	Usage         SessionUsage
+	Sessions      int
+	// Active is true while the block's window has not yet ended
+	Active bool
+}
+
+// BlockProjection extrapolates the burn rate of an active block
+type BlockProjection struct {
+	TokensPerMinute float64
+	CostPerHour     float64
+	ProjectedTokens int
+	ProjectedCost   float64
+	// Limit is the token limit used for the projection, and LimitSource says
+	// whether it came from the config or the largest previous block
+	Limit       int
+	LimitSource string
+	// Exhaustion is when the limit is reached at the current rate, zero if
+	// the block ends first
+	Exhaustion time.Time
+}
+
+// blockUsageRecord is a usage record tagged with the session it came from
+type blockUsageRecord struct {
+	usageRecord
+	session string
+}
+
+// getUsageBlocks groups the responses of every session into 5-hour blocks,
+// newest first
+func getUsageBlocks(ctx context.Context, now time.Time) ([]UsageBlock, error) {
+	sessions := listAllSessions()
+	err := forEachParallel(ctx, scanWorkers, len(sessions), func(i int) {
+		sessions[i].Session.records = readSessionUsageRecords(sessions[i].Path)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var records []blockUsageRecord
+	for i, sessionRecords := range uniqueRecords(loadedSessionInfos(sessions)) {
+		for _, record := range sessionRecords {
+			records = append(records, blockUsageRecord{usageRecord: record, session: sessions[i].Path})
+		}
+	}
+
+	return buildUsageBlocks(records, now), nil
+}
+
+func buildUsageBlocks(records []blockUsageRecord, now time.Time) []UsageBlock {
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+
+	var blocks []UsageBlock
+	var blockRecords []usageRecord
+	sessions := make(map[string]bool)
+
+	finish := func() {
+		if len(blockRecords) == 0 {
+			return
+		}
+		block := &blocks[len(blocks)-1]
+		block.Usage = summarizeUsage(blockRecords)
+		block.Sessions = len(sessions)
+		block.Active = now.Before(block.End)
+		blockRecords = nil
+		sessions = make(map[string]bool)
+	}
+
+	for _, record := range records {
+		if record.Timestamp.IsZero() {
+			continue
+		}
+		if len(blocks) == 0 || !record.Timestamp.Before(blocks[len(blocks)-1].End) {
+			finish()
+			start := record.Timestamp.Truncate(time.Hour)
+			blocks = append(blocks, UsageBlock{
+				Start:         start,
+				End:           start.Add(blockDuration),
+				FirstActivity: record.Timestamp,
+			})
+		}
+		block := &blocks[len(blocks)-1]
+		block.LastActivity = record.Timestamp
+		blockRecords = append(blockRecords, record.usageRecord)
+		sessions[record.session] = true
+	}
+	finish()
+
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+	return blocks
+}
+
+// activeBlock returns the current block, if usage has been recorded in it
+func activeBlock(blocks []UsageBlock) (UsageBlock, bool) {
+	if len(blocks) > 0 && blocks[0].Active {
+		return blocks[0], true
+	}
+	return UsageBlock{}, false
+}
+
+// blockTokenLimit returns the configured token limit, falling back to the
+// largest completed block as an estimate of the plan's limit
+func blockTokenLimit(blocks []UsageBlock) (int, string) {
+	if planLimit.Tokens > 0 {
+		return planLimit.Tokens, "config"
+	}
+	max := 0
+	for _, block := range blocks {
+		if !block.Active && block.Usage.TotalTokens() > max {
+			max = block.Usage.TotalTokens()
+		}
+	}
+	if max == 0 {
+		return 0, ""
+	}
+	return max, "largest previous block"
+}
+
+// projectBlock estimates the burn rate of a block and when it will reach
+// the token limit
+func projectBlock(block UsageBlock, blocks []UsageBlock, now time.Time) BlockProjection {
+	var projection BlockProjection
+
+	elapsed := block.LastActivity.Sub(block.FirstActivity)
+	if now.Before(block.End) && now.After(block.LastActivity) {
+		elapsed = now.Sub(block.FirstActivity)
+	}
+	if elapsed < time.Minute {
+		elapsed = time.Minute
+	}
+
+	tokens := block.Usage.TotalTokens()
+	projection.TokensPerMinute = float64(tokens) / elapsed.Minutes()
+	projection.CostPerHour = block.Usage.Cost / elapsed.Hours()
+
+	remaining := time.Duration(0)
+	if now.Before(block.End) {
+		remaining = block.End.Sub(now)
+	}
+	projection.ProjectedTokens = tokens + int(projection.TokensPerMinute*remaining.Minutes())
+	projection.ProjectedCost = block.Usage.Cost + projection.CostPerHour*remaining.Hours()
+
+	projection.Limit, projection.LimitSource = blockTokenLimit(blocks)
+	if projection.Limit > 0 && projection.TokensPerMinute > 0 && projection.ProjectedTokens > projection.Limit {
+		left := float64(projection.Limit - tokens)
+		if left < 0 {
+			left = 0
+		}
+		projection.Exhaustion = now.Add(time.Duration(left / projection.TokensPerMinute * float64(time.Minute)))
+	}
+
+	return projection
+}
+
+// formatDuration formats a duration as hours and minutes
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// percentOf returns used as a percentage of limit, capped for progress bars
+func percentOf(used, limit float64) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	percent := used / limit * 100
+	if percent > 100 {
+		return 100
+	}
+	return percent
+}
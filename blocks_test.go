@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildUsageBlocks(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	record := func(t time.Time, session string) blockUsageRecord {
+		return blockUsageRecord{
+			usageRecord: usageRecord{Timestamp: t, Model: "claude-sonnet-4-20250514", Usage: Usage{InputTokens: 10}},
+			session:     session,
+		}
+	}
+
+	type block struct {
+		start     time.Time
+		responses int
+		sessions  int
+		active    bool
+	}
+	tests := []struct {
+		name    string
+		records []blockUsageRecord
+		now     time.Time
+		// want lists the blocks newest first
+		want []block
+	}{
+		{
+			"no usage",
+			nil,
+			at(12, 0),
+			nil,
+		},
+		{
+			"block starts at the hour of the first response",
+			[]blockUsageRecord{record(at(9, 42), "a"), record(at(13, 59), "a")},
+			at(20, 0),
+			[]block{{at(9, 0), 2, 1, false}},
+		},
+		{
+			"response at the end of the window starts a new block",
+			[]blockUsageRecord{record(at(9, 42), "a"), record(at(14, 0), "a"), record(at(14, 30), "b")},
+			at(20, 0),
+			[]block{{at(14, 0), 2, 2, false}, {at(9, 0), 1, 1, false}},
+		},
+		{
+			"gaps leave no empty blocks",
+			[]blockUsageRecord{record(at(1, 0), "a"), record(at(22, 15), "a")},
+			at(23, 0),
+			[]block{{at(22, 0), 1, 1, true}, {at(1, 0), 1, 1, false}},
+		},
+		{
+			"records are sorted before splitting",
+			[]blockUsageRecord{record(at(15, 0), "b"), record(at(9, 0), "a"), record(at(10, 0), "c")},
+			at(16, 0),
+			[]block{{at(15, 0), 1, 1, true}, {at(9, 0), 2, 2, false}},
+		},
+		{
+			"responses without a timestamp are skipped",
+			[]blockUsageRecord{record(time.Time{}, "a"), record(at(9, 0), "a")},
+			at(9, 30),
+			[]block{{at(9, 0), 1, 1, true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := buildUsageBlocks(tt.records, tt.now)
+			if len(blocks) != len(tt.want) {
+				t.Fatalf("got %d blocks, want %d", len(blocks), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := blocks[i]
+				if !got.Start.Equal(want.start) || !got.End.Equal(want.start.Add(blockDuration)) {
+					t.Errorf("block %d spans %s to %s, want %s to %s", i, got.Start, got.End, want.start, want.start.Add(blockDuration))
+				}
+				if got.Usage.Messages != want.responses {
+					t.Errorf("block %d has %d responses, want %d", i, got.Usage.Messages, want.responses)
+				}
+				if got.Sessions != want.sessions {
+					t.Errorf("block %d has %d sessions, want %d", i, got.Sessions, want.sessions)
+				}
+				if got.Active != want.active {
+					t.Errorf("block %d active = %v, want %v", i, got.Active, want.active)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// noBranch labels activity outside a git repository
+const noBranch = "(no branch)"
+
+// BranchSwitch is the first entry of a session logged on a new branch
+type BranchSwitch struct {
+	EntryUUID string
+	Timestamp time.Time
+	From      string
+	To        string
+}
+
+// BranchFile counts how often tools read and changed a file on a branch
+type BranchFile struct {
+	Path    string
+	Reads   int
+	Changes int
+}
+
+// BranchSummary is the work done on one branch of a project
+type BranchSummary struct {
+	Name      string
+	Sessions  []SessionInfo
+	Usage     UsageTotals
+	ToolCalls int
+	Files     []BranchFile
+	First     time.Time
+	Last      time.Time
+}
+
+// ProjectBranches groups the sessions, cost and files of a project by branch
+type ProjectBranches struct {
+	Project   ProjectInfo
+	Branches  []BranchSummary
+	Switching []SessionInfo
+}
+
+// entriesBranches returns the branch of every entry that recorded one
+func entriesBranches(entries []LogEntry) []string {
+	var branches []string
+	for _, entry := range entries {
+		if entry.GitBranch != "" {
+			branches = append(branches, entry.GitBranch)
+		}
+	}
+	return branches
+}
+
+// branchSequence returns the distinct branches in order of first use and
+// the number of times consecutive entries changed branch
+func branchSequence(branches []string) ([]string, int) {
+	var distinct []string
+	seen := make(map[string]bool)
+	switches := 0
+	for i, branch := range branches {
+		if i > 0 && branch != branches[i-1] {
+			switches++
+		}
+		if !seen[branch] {
+			seen[branch] = true
+			distinct = append(distinct, branch)
+		}
+	}
+	return distinct, switches
+}
+
+// entriesBranchSwitches finds where a session moved to another branch
+func entriesBranchSwitches(entries []LogEntry) []BranchSwitch {
+	var switches []BranchSwitch
+	current := ""
+	for _, entry := range entries {
+		if entry.GitBranch == "" {
+			continue
+		}
+		if current != "" && entry.GitBranch != current {
+			switches = append(switches, BranchSwitch{
+				EntryUUID: entry.Uuid,
+				Timestamp: entry.Timestamp,
+				From:      current,
+				To:        entry.GitBranch,
+			})
+		}
+		current = entry.GitBranch
+	}
+	return switches
+}
+
+// getProjectBranches parses every session of a project and groups them by
+// the branches they ran on
+func getProjectBranches(ctx context.Context, rootID, projectName string) (ProjectBranches, error) {
+	project, err := getProject(ctx, rootID, projectName)
+	if err != nil {
+		return ProjectBranches{}, err
+	}
+	sessions, err := loadProjectSessions(ctx, project)
+	if err != nil {
+		return ProjectBranches{}, err
+	}
+	return buildProjectBranches(project, sessions), nil
+}
+
+// buildProjectBranches attributes each response's usage and each tool call
+// to the branch its entry was logged on, so a session that switched branches
+// is split between them
+func buildProjectBranches(project ProjectInfo, sessions []LoadedSession) ProjectBranches {
+	result := ProjectBranches{Project: project}
+	branches := make(map[string]*BranchSummary)
+	files := make(map[string]map[string]*BranchFile)
+
+	branchFor := func(name string) *BranchSummary {
+		if name == "" {
+			name = noBranch
+		}
+		branch := branches[name]
+		if branch == nil {
+			branch = &BranchSummary{Name: name}
+			branches[name] = branch
+			files[name] = make(map[string]*BranchFile)
+		}
+		return branch
+	}
+
+	records := uniqueRecords(loadedSessionInfos(sessions))
+	calls := uniqueToolCalls(sessions)
+	for s, session := range sessions {
+		if session.Session.BranchSwitches > 0 {
+			result.Switching = append(result.Switching, session.Session)
+		}
+
+		listed := make(map[string]bool)
+		for _, entry := range session.Entries {
+			if entry.Timestamp.IsZero() || (entry.Type != "user" && entry.Type != "assistant") {
+				continue
+			}
+			branch := branchFor(entry.GitBranch)
+			if !listed[branch.Name] {
+				listed[branch.Name] = true
+				branch.Sessions = append(branch.Sessions, session.Session)
+			}
+			if branch.First.IsZero() || entry.Timestamp.Before(branch.First) {
+				branch.First = entry.Timestamp
+			}
+			if entry.Timestamp.After(branch.Last) {
+				branch.Last = entry.Timestamp
+			}
+		}
+
+		for _, record := range records[s] {
+			branchFor(record.GitBranch).Usage.add(record.Model, record.Usage)
+		}
+
+		for _, call := range calls[s] {
+			branch := branchFor(call.GitBranch)
+			branch.ToolCalls++
+			filePath := toolInputFilePath(call.Use.Input)
+			if filePath == "" || call.IsError() {
+				continue
+			}
+			file := files[branch.Name][filePath]
+			if file == nil {
+				file = &BranchFile{Path: filePath}
+				files[branch.Name][filePath] = file
+			}
+			if call.Use.Name == "Read" {
+				file.Reads++
+			} else {
+				file.Changes++
+			}
+		}
+	}
+
+	for name, branch := range branches {
+		for _, file := range files[name] {
+			branch.Files = append(branch.Files, *file)
+		}
+		sort.Slice(branch.Files, func(i, j int) bool {
+			a, b := branch.Files[i], branch.Files[j]
+			if a.Changes != b.Changes {
+				return a.Changes > b.Changes
+			}
+			if a.Reads != b.Reads {
+				return a.Reads > b.Reads
+			}
+			return a.Path < b.Path
+		})
+		result.Branches = append(result.Branches, *branch)
+	}
+	sort.Slice(result.Branches, func(i, j int) bool {
+		return result.Branches[i].Last.After(result.Branches[j].Last)
+	})
+
+	return result
+}
+
+// ChangedFiles returns the number of files the branch modified
+func (b BranchSummary) ChangedFiles() int {
+	count := 0
+	for _, file := range b.Files {
+		if file.Changes > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// projectHasBranches reports whether any session of a project ran in a git
+// repository
+func projectHasBranches(project ProjectInfo) bool {
+	for _, session := range project.Sessions {
+		if len(session.Branches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// projectRelativePath shows a file relative to the project directory
+func projectRelativePath(project ProjectInfo, filePath string) string {
+	if project.Cwd != "" {
+		if rel, err := filepath.Rel(project.Cwd, filePath); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return filePath
+}
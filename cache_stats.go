@@ -0,0 +1,181 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// cacheTTL is how long the prompt cache keeps an entry without reads
+	cacheTTL = 5 * time.Minute
+	// minInvalidationTokens ignores small cache writes, which happen on
+	// every turn as new messages are appended
+	minInvalidationTokens = 10000
+)
+
+// CacheInvalidation is a response that rewrote a large part of the prompt
+// cache instead of reading it
+type CacheInvalidation struct {
+	EntryUUID string
+	Timestamp time.Time
+	Model     string
+	// Written is the number of cache creation tokens, Previous the cached
+	// context of the previous response in the same conversation chain
+	Written  int
+	Previous int
+	Gap      time.Duration
+	// Expired is true when the gap exceeded the cache TTL, so the miss was
+	// expected rather than caused by a change to the prompt
+	Expired bool
+	Cost    float64
+}
+
+// CacheStats summarises prompt cache efficiency
+type CacheStats struct {
+	ReadTokens     int
+	WriteTokens    int
+	UncachedTokens int
+	// WriteCost is what cache writes cost, WritePremium the part above the
+	// normal input price, and Savings what cache reads saved against it
+	WriteCost     float64
+	WritePremium  float64
+	Savings       float64
+	Invalidations []CacheInvalidation
+}
+
+// HitRatio returns the share of input tokens served from the cache
+func (c CacheStats) HitRatio() float64 {
+	total := c.ReadTokens + c.WriteTokens + c.UncachedTokens
+	if total == 0 {
+		return 0
+	}
+	return float64(c.ReadTokens) / float64(total)
+}
+
+// NetSavings is what caching saved after paying the write premium
+func (c CacheStats) NetSavings() float64 {
+	return c.Savings - c.WritePremium
+}
+
+// UnexpectedInvalidations counts invalidations not explained by the cache TTL
+func (c CacheStats) UnexpectedInvalidations() int {
+	count := 0
+	for _, invalidation := range c.Invalidations {
+		if !invalidation.Expired {
+			count++
+		}
+	}
+	return count
+}
+
+// InvalidationCost is what unexpected invalidations cost in cache writes
+func (c CacheStats) InvalidationCost() float64 {
+	cost := 0.0
+	for _, invalidation := range c.Invalidations {
+		if !invalidation.Expired {
+			cost += invalidation.Cost
+		}
+	}
+	return cost
+}
+
+// Merge adds another session's cache statistics to these
+func (c *CacheStats) Merge(other CacheStats) {
+	c.ReadTokens += other.ReadTokens
+	c.WriteTokens += other.WriteTokens
+	c.UncachedTokens += other.UncachedTokens
+	c.WriteCost += other.WriteCost
+	c.WritePremium += other.WritePremium
+	c.Savings += other.Savings
+	c.Invalidations = append(c.Invalidations, other.Invalidations...)
+}
+
+// cacheStats analyses the usage records of a session. Invalidations are
+// detected per conversation chain, since each subagent keeps its own cache: a
+// response whose cache write is large and covers most of the context the
+// previous response of its chain had read or written is counted as a cache
+// miss. The first response of a chain has nothing to miss.
+func cacheStats(records []usageRecord) CacheStats {
+	var stats CacheStats
+	previous := make(map[string]usageRecord)
+
+	for _, record := range records {
+		usage := record.Usage
+		stats.ReadTokens += usage.CacheReadInputTokens
+		stats.WriteTokens += usage.CacheCreationInputTokens
+		stats.UncachedTokens += usage.InputTokens
+
+		price, priced := lookupPricing(record.Model)
+		if priced {
+			stats.WriteCost += float64(usage.CacheCreationInputTokens) * price.CacheWrite / 1e6
+			stats.WritePremium += float64(usage.CacheCreationInputTokens) * (price.CacheWrite - price.Input) / 1e6
+			stats.Savings += float64(usage.CacheReadInputTokens) * (price.Input - price.CacheRead) / 1e6
+		}
+
+		prev, ok := previous[record.Chain]
+		previous[record.Chain] = record
+		if !ok {
+			continue
+		}
+		cached := prev.Usage.CacheReadInputTokens + prev.Usage.CacheCreationInputTokens
+		if usage.CacheCreationInputTokens < minInvalidationTokens || usage.CacheReadInputTokens >= cached/2 {
+			continue
+		}
+
+		gap := record.Timestamp.Sub(prev.Timestamp)
+		invalidation := CacheInvalidation{
+			EntryUUID: record.EntryUUID,
+			Timestamp: record.Timestamp,
+			Model:     record.Model,
+			Written:   usage.CacheCreationInputTokens,
+			Previous:  cached,
+			Gap:       gap,
+			Expired:   gap > cacheTTL,
+		}
+		if priced {
+			invalidation.Cost = float64(usage.CacheCreationInputTokens) * price.CacheWrite / 1e6
+		}
+		stats.Invalidations = append(stats.Invalidations, invalidation)
+	}
+
+	return stats
+}
+
+// sessionCacheStats analyses the prompt cache of a parsed session
+func sessionCacheStats(entries []LogEntry) CacheStats {
+	return cacheStats(usageRecords(entries))
+}
+
+// projectCacheStats aggregates the cache statistics of a project's sessions,
+// counting responses shared by resumed sessions once
+func projectCacheStats(project ProjectInfo) CacheStats {
+	var stats CacheStats
+	for _, records := range uniqueRecords(project.Sessions) {
+		stats.Merge(cacheStats(records))
+	}
+	return stats
+}
+
+// SessionCacheRow pairs a session with its cache statistics for ranking
+type SessionCacheRow struct {
+	Session SessionInfo
+	Stats   CacheStats
+}
+
+// worstCacheSessions returns the sessions of a project that lost the most
+// to unexpected cache invalidations
+func worstCacheSessions(project ProjectInfo, limit int) []SessionCacheRow {
+	var rows []SessionCacheRow
+	for _, session := range project.Sessions {
+		if session.Cache.UnexpectedInvalidations() > 0 {
+			rows = append(rows, SessionCacheRow{Session: session, Stats: session.Cache})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Stats.InvalidationCost() > rows[j].Stats.InvalidationCost()
+	})
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return rows
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	chartWidth        = 900
+	chartHeight       = 220
+	chartMarginLeft   = 60
+	chartMarginBottom = 30
+	chartMarginTop    = 10
+)
+
+// ChartSeries is one stacked component of a bar chart
+type ChartSeries struct {
+	Name  string
+	Color string
+}
+
+// ChartSegment is a rectangle of one series within a bar
+type ChartSegment struct {
+	Y     float64
+	H     float64
+	Color string
+	Title string
+}
+
+// ChartBar is a bar of the chart, one per bucket
+type ChartBar struct {
+	X        float64
+	W        float64
+	Label    string
+	Segments []ChartSegment
+	// ShowLabel thins out the axis labels when there are many bars
+	ShowLabel bool
+}
+
+// ChartTick is a horizontal grid line with its value label
+type ChartTick struct {
+	Y     float64
+	Label string
+}
+
+// ChartLine is a labelled horizontal reference line, such as a limit
+type ChartLine struct {
+	Y     float64
+	Label string
+}
+
+// ChartMarker is a labelled vertical line placed before a bar
+type ChartMarker struct {
+	X     float64
+	Label string
+}
+
+// BarChart is the geometry of a server-rendered SVG stacked bar chart
+type BarChart struct {
+	Title   string
+	Width   int
+	Height  int
+	Series  []ChartSeries
+	Bars    []ChartBar
+	Ticks   []ChartTick
+	Lines   []ChartLine
+	Markers []ChartMarker
+
+	top  float64
+	slot float64
+}
+
+// newBarChart lays out a stacked bar chart. values[i][s] is the value of
+// series s in bar i, and format renders values for ticks and tooltips.
+func newBarChart(title string, series []ChartSeries, labels []string, values [][]float64, format func(float64) string) BarChart {
+	return newScaledBarChart(title, series, labels, values, format, 0)
+}
+
+// newScaledBarChart lays out a bar chart whose axis reaches at least minTop,
+// so a reference line can be drawn above the tallest bar
+func newScaledBarChart(title string, series []ChartSeries, labels []string, values [][]float64, format func(float64) string, minTop float64) BarChart {
+	chart := BarChart{Title: title, Width: chartWidth, Height: chartHeight, Series: series}
+
+	maxTotal := minTop
+	for _, bar := range values {
+		total := 0.0
+		for _, v := range bar {
+			total += v
+		}
+		maxTotal = math.Max(maxTotal, total)
+	}
+	top := niceCeiling(maxTotal)
+	chart.top = top
+
+	plotWidth := float64(chartWidth - chartMarginLeft)
+	plotHeight := float64(chartHeight - chartMarginBottom - chartMarginTop)
+	baseline := float64(chartHeight - chartMarginBottom)
+
+	for i := 0; i <= 4; i++ {
+		value := top * float64(i) / 4
+		chart.Ticks = append(chart.Ticks, ChartTick{
+			Y:     baseline - plotHeight*float64(i)/4,
+			Label: format(value),
+		})
+	}
+
+	if len(values) == 0 {
+		return chart
+	}
+	slot := plotWidth / float64(len(values))
+	chart.slot = slot
+	labelEvery := int(math.Ceil(float64(len(values)) / 12))
+
+	for i, bar := range values {
+		chartBar := ChartBar{
+			X:         chartMarginLeft + float64(i)*slot + slot*0.1,
+			W:         slot * 0.8,
+			Label:     labels[i],
+			ShowLabel: i%labelEvery == 0,
+		}
+		y := baseline
+		for s, v := range bar {
+			if v <= 0 {
+				continue
+			}
+			h := v / top * plotHeight
+			y -= h
+			chartBar.Segments = append(chartBar.Segments, ChartSegment{
+				Y:     y,
+				H:     h,
+				Color: series[s].Color,
+				Title: fmt.Sprintf("%s · %s: %s", labels[i], series[s].Name, format(v)),
+			})
+		}
+		chart.Bars = append(chart.Bars, chartBar)
+	}
+
+	return chart
+}
+
+// horizontalLine returns a reference line at value on the chart's scale
+func (c BarChart) horizontalLine(value float64, label string) ChartLine {
+	plotHeight := float64(chartHeight - chartMarginBottom - chartMarginTop)
+	return ChartLine{
+		Y:     float64(chartHeight-chartMarginBottom) - value/c.top*plotHeight,
+		Label: label,
+	}
+}
+
+// barMarker returns a vertical marker just before bar i
+func (c BarChart) barMarker(i int, label string) ChartMarker {
+	return ChartMarker{X: chartMarginLeft + float64(i)*c.slot, Label: label}
+}
+
+// niceCeiling rounds a chart maximum up to 1, 2 or 5 times a power of ten
+func niceCeiling(value float64) float64 {
+	if value <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(value)))
+	for _, step := range []float64{1, 2, 5, 10} {
+		if value <= step*magnitude {
+			return step * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+func formatChartNumber(value float64) string {
+	return formatTokens(int(math.Round(value)))
+}
+
+func formatChartCost(value float64) string {
+	if value >= 100 {
+		return fmt.Sprintf("$%.0f", value)
+	}
+	return formatCost(value)
+}
+
+func svgNumber(value float64) string {
+	return fmt.Sprintf("%.1f", value)
+}
+
+// dashboardCharts builds the token, cost, session and tool call charts
+func dashboardCharts(d Dashboard) []BarChart {
+	labels := make([]string, len(d.Buckets))
+	tokens := make([][]float64, len(d.Buckets))
+	costs := make([][]float64, len(d.Buckets))
+	activity := make([][]float64, len(d.Buckets))
+	toolCalls := make([][]float64, len(d.Buckets))
+
+	for i, bucket := range d.Buckets {
+		labels[i] = d.bucketLabel(bucket)
+		tokens[i] = []float64{
+			float64(bucket.InputTokens),
+			float64(bucket.OutputTokens),
+			float64(bucket.CacheCreationTokens),
+			float64(bucket.CacheReadTokens),
+		}
+		costs[i] = []float64{bucket.Cost}
+		activity[i] = []float64{float64(bucket.Sessions)}
+		toolCalls[i] = []float64{float64(bucket.ToolCalls)}
+	}
+
+	return []BarChart{
+		newBarChart("Tokens", []ChartSeries{
+			{Name: "Input", Color: "#3b82f6"},
+			{Name: "Output", Color: "#10b981"},
+			{Name: "Cache write", Color: "#f59e0b"},
+			{Name: "Cache read", Color: "#a78bfa"},
+		}, labels, tokens, formatChartNumber),
+		newBarChart("Estimated cost", []ChartSeries{{Name: "Cost", Color: "#16a34a"}}, labels, costs, formatChartCost),
+		newBarChart("Active sessions", []ChartSeries{{Name: "Sessions", Color: "#6366f1"}}, labels, activity, formatChartNumber),
+		newBarChart("Tool calls", []ChartSeries{{Name: "Tool calls", Color: "#ec4899"}}, labels, toolCalls, formatChartNumber),
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// commands are the CLI subcommands, selected by the first positional argument
+var commands = map[string]func(args []string) error{
+	"query":  runQueryCommand,
+	"bash":   runBashCommand,
+	"blocks": runBlocksCommand,
+	"stats":  runStatsCommand,
+}
+
+// runQueryCommand searches all sessions from the command line using the
+// same query syntax as the web search box
+func runQueryCommand(args []string) error {
+	input := strings.Join(args, " ")
+	if strings.TrimSpace(input) == "" {
+		return fmt.Errorf("usage: query <text and filters>\nfilters: %s", queryHelp)
+	}
+
+	query, err := ParseQuery(input, time.Now())
+	if err != nil {
+		return err
+	}
+
+	index, err := getSearchIndex(context.Background())
+	if err != nil {
+		return err
+	}
+
+	results, total := index.Search(query)
+	for _, result := range results {
+		snippet := ""
+		for _, part := range result.Snippet {
+			snippet += part.Text
+		}
+		fmt.Printf("%s  %-20s %s  %-14s %s\n",
+			result.Timestamp.Local().Format("2006-01-02 15:04"),
+			truncateTitle(result.Project.DisplayName),
+			result.Session.UUID[:8],
+			searchKindLabel(result.Kind, result.Tool),
+			strings.TrimSpace(snippet))
+	}
+
+	if total > len(results) {
+		fmt.Printf("\n%d matches (showing top %d)\n", total, len(results))
+	} else {
+		fmt.Printf("\n%d matches\n", total)
+	}
+	return nil
+}
+
+// runBashCommand prints the Bash commands run by the agent, newest last like
+// a shell history, or the most frequent commands with --top
+func runBashCommand(args []string) error {
+	flags := flag.NewFlagSet("bash", flag.ContinueOnError)
+	top := flags.Int("top", 0, "show the N most frequent commands instead of the history")
+	limit := flags.Int("n", 50, "number of history lines to show (0 for all)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	records, err := getBashHistory(context.Background())
+	if err != nil {
+		return err
+	}
+	records = filterBashHistory(records, strings.Join(flags.Args(), " "))
+
+	if *top > 0 {
+		for i, count := range bashCommandFrequencies(records) {
+			if i >= *top {
+				break
+			}
+			fmt.Printf("%6d  %4d errors  %s\n", count.Count, count.Errors, singleLine(count.Command))
+		}
+		return nil
+	}
+
+	if *limit > 0 && len(records) > *limit {
+		records = records[:*limit]
+	}
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		fmt.Printf("%s  %-20s %-7s %s\n",
+			record.Timestamp.Local().Format("2006-01-02 15:04:05"),
+			truncateTitle(record.Project.DisplayName),
+			record.Status(),
+			singleLine(record.Command))
+	}
+	return nil
+}
+
+// runBlocksCommand prints the current 5-hour usage block with its burn rate
+// and projection, followed by previous blocks
+func runBlocksCommand(args []string) error {
+	flags := flag.NewFlagSet("blocks", flag.ContinueOnError)
+	limit := flags.Int("n", 10, "number of blocks to show (0 for all)")
+	tokenLimit := flags.Int("token-limit", 0, "token limit per block, overriding the config file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *tokenLimit > 0 {
+		planLimit.Tokens = *tokenLimit
+	}
+
+	now := time.Now()
+	blocks, err := getUsageBlocks(context.Background(), now)
+	if err != nil {
+		return err
+	}
+
+	if block, ok := activeBlock(blocks); ok {
+		projection := projectBlock(block, blocks, now)
+		fmt.Printf("Current block: %s - %s (%s remaining)\n",
+			block.Start.Local().Format("2006-01-02 15:04"),
+			block.End.Local().Format("15:04"),
+			formatDuration(block.End.Sub(now)))
+		fmt.Printf("  Used:       %s tokens, %s\n", formatTokens(block.Usage.TotalTokens()), formatCost(block.Usage.Cost))
+		fmt.Printf("  Burn rate:  %s tokens/min, %s/hour\n", formatTokens(int(projection.TokensPerMinute)), formatCost(projection.CostPerHour))
+		fmt.Printf("  Projected:  %s tokens, %s by block end\n", formatTokens(projection.ProjectedTokens), formatCost(projection.ProjectedCost))
+		if projection.Limit > 0 {
+			fmt.Printf("  Limit:      %s tokens (%s), %.0f%% used\n", formatTokens(projection.Limit), projection.LimitSource,
+				percentOf(float64(block.Usage.TotalTokens()), float64(projection.Limit)))
+			if !projection.Exhaustion.IsZero() {
+				fmt.Printf("  Exhausted:  around %s at the current rate\n", projection.Exhaustion.Local().Format("15:04"))
+			}
+		}
+		if planLimit.Cost > 0 {
+			fmt.Printf("  Cost limit: %s, %.0f%% used\n", formatCost(planLimit.Cost), percentOf(block.Usage.Cost, planLimit.Cost))
+		}
+		fmt.Println()
+	} else {
+		fmt.Printf("No active block\n\n")
+	}
+
+	if *limit > 0 && len(blocks) > *limit {
+		blocks = blocks[:*limit]
+	}
+	for _, block := range blocks {
+		status := ""
+		if block.Active {
+			status = "active"
+		}
+		fmt.Printf("%s - %s  %8s tokens  %9s  %3d sessions  %s\n",
+			block.Start.Local().Format("2006-01-02 15:04"),
+			block.End.Local().Format("15:04"),
+			formatTokens(block.Usage.TotalTokens()),
+			formatCost(block.Usage.Cost),
+			block.Sessions,
+			status)
+	}
+	return nil
+}
+
+// runStatsCommand prints tool error rates, the most common errors and the
+// inputs that fail most often
+func runStatsCommand(args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	sinceValue := flags.String("since", "", "only include tool calls since a date or duration, e.g. 7d")
+	top := flags.Int("top", 10, "number of errors and inputs to show")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var since time.Time
+	if *sinceValue != "" {
+		t, err := parseQueryTime(*sinceValue, now, false)
+		if err != nil {
+			return err
+		}
+		since = t
+	}
+
+	report, err := getToolErrorReport(context.Background(), since, now)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d tool calls, %d errors (%s)\n\n", report.Calls, report.Errors, formatPercent(report.Rate()))
+
+	fmt.Println("Errors by tool:")
+	for _, rate := range report.Tools {
+		fmt.Printf("  %-20s %6d calls %5d errors %7s\n", rate.Name, rate.Calls, rate.Errors, formatPercent(rate.Rate()))
+	}
+
+	fmt.Println("\nErrors by project:")
+	for _, rate := range report.Projects {
+		fmt.Printf("  %-30s %6d calls %5d errors %7s\n", truncateRunes(rate.Name, 30), rate.Calls, rate.Errors, formatPercent(rate.Rate()))
+	}
+
+	fmt.Println("\nMost common errors:")
+	for i, cluster := range report.Clusters {
+		if i >= *top {
+			break
+		}
+		fmt.Printf("  %5d  [%s] %s\n", cluster.Count, strings.Join(cluster.Tools, ","), cluster.Pattern)
+	}
+
+	fmt.Println("\nInputs that fail most often:")
+	for i, input := range report.Inputs {
+		if i >= *top {
+			break
+		}
+		fmt.Printf("  %3d/%-3d %-10s %s\n", input.Failures, input.Calls, input.Tool, input.Input)
+	}
+	return nil
+}
+
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
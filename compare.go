@@ -0,0 +1,461 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// readLinePrefix is the line number Claude Code puts before each line of a
+// Read result, followed by a tab or an arrow
+var readLinePrefix = regexp.MustCompile(`^\s*\d+(\t|→)`)
+
+const (
+	// readLineLimit is the number of lines a Read returns by default
+	readLineLimit = 2000
+	// readLineLength is the length beyond which Read truncates a line
+	readLineLength = 2000
+)
+
+// FileState is a file as a session left it, reconstructed from its tool calls
+type FileState struct {
+	Path string
+	// Key is the path relative to the project, so the same file matches
+	// between sessions run in different checkouts
+	Key     string
+	Content string
+	// Complete is false when the file's content before the session edited
+	// it was never seen, so its final content is unknown
+	Complete     bool
+	Edits        int
+	LinesAdded   int
+	LinesRemoved int
+}
+
+// ComparePrompt is a prompt typed by the user
+type ComparePrompt struct {
+	EntryUUID string
+	Timestamp time.Time
+	Text      string
+}
+
+// PromptPair is a row of aligned prompts; either side can be missing
+type PromptPair struct {
+	A    *ComparePrompt
+	B    *ComparePrompt
+	Same bool
+}
+
+// ToolComparison counts the calls of one tool in both sessions
+type ToolComparison struct {
+	Tool string
+	A    int
+	B    int
+}
+
+// FileComparison is a file changed by either session with the diff between
+// their final versions
+type FileComparison struct {
+	Key      string
+	A        *FileState
+	B        *FileState
+	Hunks    []DiffHunk
+	Diffable bool
+}
+
+// CompareSide is one of the two compared sessions
+type CompareSide struct {
+	Session    LoadedSession
+	Usage      SessionUsage
+	Activity   SessionActivity
+	Models     []ResponseCount
+	Prompts    []ComparePrompt
+	ToolCalls  int
+	ToolErrors int
+	Friction   FrictionCounts
+	Turns      LatencyDistribution
+	Files      []FileState
+}
+
+// Comparison sets two sessions side by side
+type Comparison struct {
+	A       CompareSide
+	B       CompareSide
+	Prompts []PromptPair
+	Tools   []ToolComparison
+	Files   []FileComparison
+}
+
+// findSessionByUUID locates a session by its UUID or a unique prefix of it,
+// and parses it. The ID can be given as root/uuid to pick one of the data
+// roots a session was copied into.
+func findSessionByUUID(ctx context.Context, id string) (LoadedSession, error) {
+	id = strings.TrimSpace(id)
+	rootID := ""
+	if i := strings.Index(id, "/"); i >= 0 {
+		rootID, id = id[:i], id[i+1:]
+	}
+	if len(id) < 8 {
+		return LoadedSession{}, fmt.Errorf("session ID %q is too short", id)
+	}
+
+	var exact, prefixed []LoadedSession
+	for _, session := range listAllSessions() {
+		if rootID != "" && session.Project.Root.ID != rootID {
+			continue
+		}
+		if session.Session.UUID == id {
+			exact = append(exact, session)
+		} else if strings.HasPrefix(session.Session.UUID, id) {
+			prefixed = append(prefixed, session)
+		}
+	}
+	matches := exact
+	if len(matches) == 0 {
+		matches = prefixed
+	}
+	if len(matches) == 0 {
+		return LoadedSession{}, fmt.Errorf("session %s not found", id)
+	}
+	var rootIDs []string
+	for _, session := range matches {
+		if session.Session.UUID != matches[0].Session.UUID {
+			return LoadedSession{}, fmt.Errorf("session ID %s is ambiguous", id)
+		}
+		rootIDs = append(rootIDs, session.Project.Root.ID)
+	}
+	if len(matches) > 1 {
+		return LoadedSession{}, fmt.Errorf("session %s is in several data roots (%s); give it as root/uuid", matches[0].Session.UUID, strings.Join(rootIDs, ", "))
+	}
+
+	sessions, err := parseSessions(ctx, matches)
+	if err != nil {
+		return LoadedSession{}, err
+	}
+	if sessions[0].Entries == nil {
+		return LoadedSession{}, fmt.Errorf("error parsing session %s", id)
+	}
+	return sessions[0], nil
+}
+
+// getComparison loads and compares two sessions
+func getComparison(ctx context.Context, a, b string) (Comparison, error) {
+	sessionA, err := findSessionByUUID(ctx, a)
+	if err != nil {
+		return Comparison{}, err
+	}
+	sessionB, err := findSessionByUUID(ctx, b)
+	if err != nil {
+		return Comparison{}, err
+	}
+	return buildComparison(sessionA, sessionB), nil
+}
+
+func buildComparison(a, b LoadedSession) Comparison {
+	comparison := Comparison{A: compareSide(a), B: compareSide(b)}
+	comparison.Prompts = alignPrompts(comparison.A.Prompts, comparison.B.Prompts)
+
+	countsA, countsB := getToolCounts(a.Entries), getToolCounts(b.Entries)
+	for tool, count := range countsA {
+		comparison.Tools = append(comparison.Tools, ToolComparison{Tool: tool, A: count, B: countsB[tool]})
+	}
+	for tool, count := range countsB {
+		if _, ok := countsA[tool]; !ok {
+			comparison.Tools = append(comparison.Tools, ToolComparison{Tool: tool, B: count})
+		}
+	}
+	sort.Slice(comparison.Tools, func(i, j int) bool {
+		x, y := comparison.Tools[i], comparison.Tools[j]
+		if x.A+x.B != y.A+y.B {
+			return x.A+x.B > y.A+y.B
+		}
+		return x.Tool < y.Tool
+	})
+
+	files := make(map[string]*FileComparison)
+	var keys []string
+	fileFor := func(key string) *FileComparison {
+		if files[key] == nil {
+			files[key] = &FileComparison{Key: key}
+			keys = append(keys, key)
+		}
+		return files[key]
+	}
+	for i := range comparison.A.Files {
+		fileFor(comparison.A.Files[i].Key).A = &comparison.A.Files[i]
+	}
+	for i := range comparison.B.Files {
+		fileFor(comparison.B.Files[i].Key).B = &comparison.B.Files[i]
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		file := files[key]
+		if file.A != nil && file.B != nil && file.A.Complete && file.B.Complete {
+			file.Hunks, file.Diffable = lineDiff(file.A.Content, file.B.Content)
+		}
+		comparison.Files = append(comparison.Files, *file)
+	}
+
+	return comparison
+}
+
+func compareSide(session LoadedSession) CompareSide {
+	records := usageRecords(session.Entries)
+	side := CompareSide{
+		Session:  session,
+		Usage:    summarizeUsage(records),
+		Activity: entriesActivity(session.Entries),
+		Models:   buildResponseBreakdown([]sessionRecords{{Records: records}}).Models,
+		Files:    finalFileStates(session),
+	}
+	for _, entry := range session.Entries {
+		if entry.Type != "user" || entry.IsSidechain || promptTitle(entry) == "" {
+			continue
+		}
+		text := strings.TrimSpace(systemReminderPattern.ReplaceAllString(entryText(entry), ""))
+		side.Prompts = append(side.Prompts, ComparePrompt{EntryUUID: entry.Uuid, Timestamp: entry.Timestamp, Text: text})
+	}
+	for _, call := range pairToolCalls(session.Entries) {
+		side.ToolCalls++
+		if call.IsError() {
+			side.ToolErrors++
+		}
+	}
+	friction := buildFrictionReport([]LoadedSession{session})
+	side.Friction = friction.Totals
+	side.Turns = buildLatencyReport([]LoadedSession{session}).Turns
+	return side
+}
+
+// alignPrompts pairs up the prompts of two sessions. Prompts with the same
+// text are matched along a shortest edit script, and the prompts between two
+// matches are paired in order.
+func alignPrompts(a, b []ComparePrompt) []PromptPair {
+	normalize := func(prompts []ComparePrompt) []string {
+		texts := make([]string, len(prompts))
+		for i, p := range prompts {
+			texts[i] = strings.ToLower(strings.Join(strings.Fields(p.Text), " "))
+		}
+		return texts
+	}
+
+	var pairs []PromptPair
+	var pendingA, pendingB []int
+	flush := func() {
+		for k := 0; k < max(len(pendingA), len(pendingB)); k++ {
+			var pair PromptPair
+			if k < len(pendingA) {
+				pair.A = &a[pendingA[k]]
+			}
+			if k < len(pendingB) {
+				pair.B = &b[pendingB[k]]
+			}
+			pairs = append(pairs, pair)
+		}
+		pendingA, pendingB = nil, nil
+	}
+
+	i, j := 0, 0
+	for _, op := range editScript(normalize(a), normalize(b)) {
+		switch op {
+		case ' ':
+			flush()
+			pairs = append(pairs, PromptPair{A: &a[i], B: &b[j], Same: true})
+			i++
+			j++
+		case '-':
+			pendingA = append(pendingA, i)
+			i++
+		case '+':
+			pendingB = append(pendingB, j)
+			j++
+		}
+	}
+	flush()
+	return pairs
+}
+
+// finalFileStates replays a session's successful Read, Edit, MultiEdit and
+// Write calls to reconstruct every file it changed. A full Read or a Write
+// reveals a file's content; edits are applied on top of it.
+func finalFileStates(session LoadedSession) []FileState {
+	entries := make(map[string]LogEntry, len(session.Entries))
+	for _, entry := range session.Entries {
+		entries[entry.Uuid] = entry
+	}
+
+	states := make(map[string]*FileState)
+	var order []string
+	stateFor := func(filePath string) *FileState {
+		if states[filePath] == nil {
+			states[filePath] = &FileState{Path: filePath, Key: projectRelativePath(session.Project, filePath)}
+			order = append(order, filePath)
+		}
+		return states[filePath]
+	}
+
+	for _, call := range pairToolCalls(session.Entries) {
+		if call.Result == nil || call.IsError() {
+			continue
+		}
+		switch in := call.Use.Input.(type) {
+		case ReadInput:
+			if in.Offset != nil || in.Limit != nil {
+				continue
+			}
+			content := readResultContent(call.ResultText())
+			if !readResultComplete(entries[call.ResultEntryUUID], content) {
+				continue
+			}
+			state := stateFor(in.FilePath)
+			state.Content = content
+			state.Complete = true
+		case WriteInput:
+			state := stateFor(in.FilePath)
+			added, removed := toolLineChanges(in)
+			if state.Complete {
+				added, removed = lineChangeCounts(state.Content, in.Content)
+			}
+			state.Content = in.Content
+			state.Complete = true
+			state.Edits++
+			state.LinesAdded += added
+			state.LinesRemoved += removed
+		case EditInput:
+			state := stateFor(in.FilePath)
+			if !state.Complete {
+				if original, ok := originalFile(entries[call.ResultEntryUUID]); ok {
+					state.Content = original
+					state.Complete = true
+				}
+			}
+			state.Content = applyEdit(state.Content, in.OldString, in.NewString, in.ReplaceAll != nil && *in.ReplaceAll)
+			state.recordEdit(in)
+		case MultiEditInput:
+			state := stateFor(in.FilePath)
+			if !state.Complete {
+				if original, ok := originalFile(entries[call.ResultEntryUUID]); ok {
+					state.Content = original
+					state.Complete = true
+				}
+			}
+			for _, edit := range in.Edits {
+				state.Content = applyEdit(state.Content, edit.OldString, edit.NewString, edit.ReplaceAll != nil && *edit.ReplaceAll)
+			}
+			state.recordEdit(in)
+		}
+	}
+
+	var changed []FileState
+	for _, filePath := range order {
+		if state := states[filePath]; state.Edits > 0 {
+			changed = append(changed, *state)
+		}
+	}
+	return changed
+}
+
+func (s *FileState) recordEdit(input ToolInput) {
+	added, removed := toolLineChanges(input)
+	s.Edits++
+	s.LinesAdded += added
+	s.LinesRemoved += removed
+}
+
+func applyEdit(content, oldString, newString string, replaceAll bool) string {
+	if replaceAll {
+		return strings.ReplaceAll(content, oldString, newString)
+	}
+	return strings.Replace(content, oldString, newString, 1)
+}
+
+// lineChangeCounts counts the lines added and removed between two versions
+func lineChangeCounts(oldText, newText string) (int, int) {
+	hunks, ok := lineDiff(oldText, newText)
+	if !ok {
+		return countLines(newText), countLines(oldText)
+	}
+	added, removed := 0, 0
+	for _, hunk := range hunks {
+		for _, line := range hunk.Lines {
+			switch line.Kind {
+			case '+':
+				added++
+			case '-':
+				removed++
+			}
+		}
+	}
+	return added, removed
+}
+
+// originalFile returns the file content before an edit, which newer versions
+// of Claude Code record in the tool result metadata
+func originalFile(entry LogEntry) (string, bool) {
+	result, ok := entry.ToolUseResult.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	original, ok := result["originalFile"].(string)
+	return original, ok
+}
+
+// readResultComplete reports whether a Read without offset or limit returned
+// the whole file. Newer Claude Code versions record the file's line count;
+// otherwise a result that reached the default line limit is assumed to be
+// cut off. Lines over the length limit are truncated either way.
+func readResultComplete(entry LogEntry, content string) bool {
+	lines := splitLines(content)
+	counted := false
+	if result, ok := entry.ToolUseResult.(map[string]interface{}); ok {
+		if file, ok := result["file"].(map[string]interface{}); ok {
+			numLines, okNum := file["numLines"].(float64)
+			totalLines, okTotal := file["totalLines"].(float64)
+			if okNum && okTotal {
+				if numLines < totalLines {
+					return false
+				}
+				counted = true
+			}
+		}
+	}
+	if !counted && len(lines) >= readLineLimit {
+		return false
+	}
+	for _, line := range lines {
+		if utf8.RuneCountInString(line) >= readLineLength {
+			return false
+		}
+	}
+	return true
+}
+
+// readResultContent recovers file content from a Read result by removing the
+// line numbers and any system reminders
+func readResultContent(text string) string {
+	text = systemReminderPattern.ReplaceAllString(text, "")
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if loc := readLinePrefix.FindStringIndex(line); loc != nil {
+			lines = append(lines, line[loc[1]:])
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// entriesSessionID returns the session ID recorded in a session's entries
+func entriesSessionID(entries []LogEntry) string {
+	for _, entry := range entries {
+		if entry.SessionId != "" {
+			return entry.SessionId
+		}
+	}
+	return ""
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Config holds the settings that can be provided through the JSON config file
+type Config struct {
+	Roots   []RootConfig            `json:"roots"`
+	Workers int                     `json:"workers"`
+	Pricing map[string]ModelPricing `json:"pricing"`
+	// PlanLimit is the usage allowed per 5-hour block
+	PlanLimit PlanLimit `json:"plan_limit"`
+	// IdleMinutes is the gap between entries that ends a work period
+	IdleMinutes int `json:"idle_minutes"`
+	// SessionCacheMB bounds the size of the session files kept parsed in
+	// memory by the web server
+	SessionCacheMB int `json:"session_cache_mb"`
+}
+
+// RootConfig describes a Claude data directory to browse
+type RootConfig struct {
+	Label string `json:"label"`
+	Path  string `json:"path"`
+}
+
+// DataRoot is a resolved Claude projects directory shown as a source in the UI
+type DataRoot struct {
+	ID    string
+	Label string
+	Path  string
+}
+
+// roots holds the data roots the server and CLI read sessions from
+var roots []DataRoot
+
+// scanWorkers bounds the number of session files read concurrently
+var scanWorkers = runtime.NumCPU()
+
+// rootFlag collects repeated --root flags
+type rootFlag []string
+
+func (r *rootFlag) String() string {
+	return strings.Join(*r, ",")
+}
+
+func (r *rootFlag) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
+
+func defaultConfigPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(configDir, "claude-code-browser", "config.json")
+}
+
+func loadConfig(path string) (Config, error) {
+	var config Config
+	if path == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return config, fmt.Errorf("error reading config file: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+	return config, nil
+}
+
+// defaultClaudeDir returns the Claude config directory, honouring CLAUDE_CONFIG_DIR
+func defaultClaudeDir() string {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	return os.ExpandEnv("$HOME/.claude")
+}
+
+// resolveRoots builds the list of data roots. Roots given on the command line
+// take precedence over the config file; with neither, the local Claude
+// directory is used.
+func resolveRoots(flagRoots []string, config Config) []DataRoot {
+	var configs []RootConfig
+	for _, value := range flagRoots {
+		configs = append(configs, parseRootFlag(value))
+	}
+	if len(configs) == 0 {
+		configs = config.Roots
+	}
+	if len(configs) == 0 {
+		configs = []RootConfig{{Label: "local", Path: defaultClaudeDir()}}
+	}
+
+	var resolved []DataRoot
+	used := make(map[string]bool)
+	for _, rc := range configs {
+		path := projectsDir(expandPath(rc.Path))
+		if absPath, err := filepath.Abs(path); err == nil {
+			path = absPath
+		}
+		label := rc.Label
+		if label == "" {
+			label = defaultRootLabel(path)
+		}
+
+		// Repeated labels get a numbered suffix, skipping IDs another label
+		// already slugifies to
+		base := slugify(label)
+		id := base
+		for n := 2; used[id]; n++ {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[id] = true
+
+		resolved = append(resolved, DataRoot{ID: id, Label: label, Path: path})
+	}
+	return resolved
+}
+
+// expandPath expands environment variables and a leading ~ in a path
+func expandPath(path string) string {
+	path = os.ExpandEnv(path)
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// parseRootFlag parses a --root value of the form [label=]path
+func parseRootFlag(value string) RootConfig {
+	if label, path, ok := strings.Cut(value, "="); ok && label != "" && !strings.ContainsRune(label, filepath.Separator) {
+		return RootConfig{Label: label, Path: path}
+	}
+	return RootConfig{Path: value}
+}
+
+// projectsDir accepts either a Claude config directory or its projects
+// subdirectory and returns the projects directory
+func projectsDir(path string) string {
+	path = filepath.Clean(path)
+	if filepath.Base(path) == "projects" {
+		return path
+	}
+	if info, err := os.Stat(filepath.Join(path, "projects")); err == nil && info.IsDir() {
+		return filepath.Join(path, "projects")
+	}
+	return path
+}
+
+func defaultRootLabel(path string) string {
+	dir := path
+	for _, skip := range []string{"projects", ".claude"} {
+		if filepath.Base(dir) == skip {
+			dir = filepath.Dir(dir)
+		}
+	}
+	if base := filepath.Base(dir); base != "." && base != string(filepath.Separator) {
+		return base
+	}
+	return "root"
+}
+
+func slugify(text string) string {
+	var b strings.Builder
+	lastDash := false
+	for _, r := range strings.ToLower(text) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			lastDash = false
+		} else if !lastDash && b.Len() > 0 {
+			b.WriteRune('-')
+			lastDash = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		return "root"
+	}
+	return slug
+}
+
+func findRoot(id string) (DataRoot, bool) {
+	for _, root := range roots {
+		if root.ID == id {
+			return root, true
+		}
+	}
+	return DataRoot{}, false
+}
+
+// locateSession finds the data root and project directory containing a session file
+func locateSession(sessionPath string) (DataRoot, string, bool) {
+	absPath, err := filepath.Abs(sessionPath)
+	if err != nil {
+		return DataRoot{}, "", false
+	}
+
+	for _, root := range roots {
+		rel, err := filepath.Rel(root.Path, absPath)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		parts := strings.Split(rel, string(filepath.Separator))
+		if len(parts) == 2 {
+			return root, parts[0], true
+		}
+	}
+	return DataRoot{}, "", false
+}
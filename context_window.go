@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultContextLimit  = 200000
+	extendedContextLimit = 1000000
+	// maxContextJumps is the number of largest context increases explained
+	maxContextJumps = 5
+	// maxJumpContributors is the number of tool results listed per jump
+	maxJumpContributors = 3
+)
+
+// contextLimits maps model name prefixes to their standard context window in
+// tokens. The longest matching prefix wins; unknown models get
+// defaultContextLimit.
+var contextLimits = map[string]int{
+	"claude-instant":  100000,
+	"claude-2.0":      100000,
+	"claude-2.1":      200000,
+	"claude-3":        200000,
+	"claude-haiku-4":  200000,
+	"claude-sonnet-4": 200000,
+	"claude-opus-4":   200000,
+}
+
+// extendedContextModels are the model name prefixes that can run with the 1M
+// context window. The logs do not record whether it was enabled.
+var extendedContextModels = []string{"claude-sonnet-4"}
+
+// ContextTurn is the context size of one main-conversation API response
+type ContextTurn struct {
+	EntryUUID string
+	Timestamp time.Time
+	Model     string
+	Tokens    int
+	Usage     Usage
+}
+
+// ContextCompaction marks where the conversation was compacted
+type ContextCompaction struct {
+	EntryUUID string
+	Timestamp time.Time
+	// Turn is the index of the first turn after the compaction
+	Turn      int
+	Trigger   string
+	PreTokens int
+}
+
+// ContextContributor is a tool result added to the context between turns
+type ContextContributor struct {
+	EntryUUID string
+	Tool      string
+	Summary   string
+	// Tokens is estimated from the result's length
+	Tokens int
+}
+
+// ContextJump is a large increase in context size between two turns
+type ContextJump struct {
+	Turn         int
+	From         int
+	To           int
+	Contributors []ContextContributor
+}
+
+// ContextWindow is the context size of a session over its turns
+type ContextWindow struct {
+	Turns []ContextTurn
+	Limit int
+	// Extended is set when the session went past the model's standard window,
+	// so it must have used the 1M context
+	Extended    bool
+	Peak        int
+	Compactions []ContextCompaction
+	Jumps       []ContextJump
+}
+
+// contextLimit returns the context window of a model and whether it is the
+// extended one. Sessions of a model that supports the 1M context and went
+// past the standard window must have used it.
+func contextLimit(model string, peak int) (int, bool) {
+	limit := defaultContextLimit
+	best := ""
+	for prefix, l := range contextLimits {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+			limit = l
+		}
+	}
+	if peak > limit {
+		for _, prefix := range extendedContextModels {
+			if strings.HasPrefix(model, prefix) {
+				return extendedContextLimit, true
+			}
+		}
+	}
+	return limit, false
+}
+
+// sessionContextWindow measures the context of each main-chain response as
+// the sum of its input, cache creation and cache read tokens, and explains
+// the largest increases with the tool results added just before them
+func sessionContextWindow(entries []LogEntry) ContextWindow {
+	var window ContextWindow
+
+	entryIndex := make(map[string]int, len(entries))
+	for i, entry := range entries {
+		entryIndex[entry.Uuid] = i
+	}
+
+	model := ""
+	for _, record := range usageRecords(entries) {
+		if record.Sidechain {
+			continue
+		}
+		usage := record.Usage
+		tokens := usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+		window.Turns = append(window.Turns, ContextTurn{
+			EntryUUID: record.EntryUUID,
+			Timestamp: record.Timestamp,
+			Model:     record.Model,
+			Tokens:    tokens,
+			Usage:     usage,
+		})
+		if tokens > window.Peak {
+			window.Peak = tokens
+		}
+		if record.Model != "<synthetic>" {
+			model = record.Model
+		}
+	}
+	window.Limit, window.Extended = contextLimit(model, window.Peak)
+
+	turnIndex := 0
+	for i, entry := range entries {
+		isBoundary := entry.Type == "system" && entry.Subtype == "compact_boundary"
+		if !isBoundary {
+			continue
+		}
+		for turnIndex < len(window.Turns) && entryIndex[window.Turns[turnIndex].EntryUUID] < i {
+			turnIndex++
+		}
+		compaction := ContextCompaction{EntryUUID: entry.Uuid, Timestamp: entry.Timestamp, Turn: turnIndex}
+		if entry.CompactMetadata != nil {
+			compaction.Trigger = entry.CompactMetadata.Trigger
+			compaction.PreTokens = entry.CompactMetadata.PreTokens
+		}
+		window.Compactions = append(window.Compactions, compaction)
+	}
+
+	window.Jumps = contextJumps(entries, window.Turns, entryIndex)
+	return window
+}
+
+func contextJumps(entries []LogEntry, turns []ContextTurn, entryIndex map[string]int) []ContextJump {
+	var jumps []ContextJump
+	for i := 1; i < len(turns); i++ {
+		if turns[i].Tokens > turns[i-1].Tokens {
+			jumps = append(jumps, ContextJump{Turn: i, From: turns[i-1].Tokens, To: turns[i].Tokens})
+		}
+	}
+	sort.SliceStable(jumps, func(i, j int) bool {
+		return jumps[i].To-jumps[i].From > jumps[j].To-jumps[j].From
+	})
+	if len(jumps) > maxContextJumps {
+		jumps = jumps[:maxContextJumps]
+	}
+
+	calls := toolCallsByID(pairToolCalls(entries))
+	for j := range jumps {
+		start := entryIndex[turns[jumps[j].Turn-1].EntryUUID]
+		end := entryIndex[turns[jumps[j].Turn].EntryUUID]
+
+		var contributors []ContextContributor
+		for _, entry := range entries[start+1 : end] {
+			blocks, ok := entry.Message.Content.([]ContentBlock)
+			if !ok || entry.IsSidechain {
+				continue
+			}
+			for _, block := range blocks {
+				result, ok := block.(*ToolResultBlock)
+				if !ok {
+					continue
+				}
+				contributor := ContextContributor{
+					EntryUUID: entry.Uuid,
+					Tokens:    len(toolResultText(result.Content)) / 4,
+				}
+				if call, ok := calls[result.ToolUseId]; ok {
+					contributor.Tool = call.Use.Name
+					contributor.Summary = toolCallSummary(call)
+				}
+				contributors = append(contributors, contributor)
+			}
+		}
+		sort.SliceStable(contributors, func(a, b int) bool {
+			return contributors[a].Tokens > contributors[b].Tokens
+		})
+		if len(contributors) > maxJumpContributors {
+			contributors = contributors[:maxJumpContributors]
+		}
+		jumps[j].Contributors = contributors
+	}
+
+	return jumps
+}
+
+// toolCallSummary describes a tool call in a few words, such as the file it
+// read or the command it ran
+func toolCallSummary(call ToolCall) string {
+	if filePath := toolInputFilePath(call.Use.Input); filePath != "" {
+		return filePath
+	}
+	switch in := call.Use.Input.(type) {
+	case BashInput:
+		return truncateTitle(singleLine(in.Command))
+	}
+	for _, text := range collectStrings(call.Use.Input) {
+		if text != "" {
+			return truncateTitle(singleLine(text))
+		}
+	}
+	return ""
+}
+
+// contextChart draws the context size of each turn, split by token class
+func contextChart(window ContextWindow) BarChart {
+	labels := make([]string, len(window.Turns))
+	values := make([][]float64, len(window.Turns))
+	for i, turn := range window.Turns {
+		labels[i] = fmt.Sprintf("Turn %d", i+1)
+		values[i] = []float64{
+			float64(turn.Usage.CacheReadInputTokens),
+			float64(turn.Usage.CacheCreationInputTokens),
+			float64(turn.Usage.InputTokens),
+		}
+	}
+
+	chart := newScaledBarChart("Context window", []ChartSeries{
+		{Name: "Cache read", Color: "#a78bfa"},
+		{Name: "Cache write", Color: "#f59e0b"},
+		{Name: "Input", Color: "#3b82f6"},
+	}, labels, values, formatChartNumber, float64(window.Limit))
+
+	chart.Lines = append(chart.Lines, chart.horizontalLine(float64(window.Limit), "Limit "+formatTokens(window.Limit)))
+	for _, compaction := range window.Compactions {
+		label := "Compacted"
+		if compaction.Trigger != "" {
+			label += " (" + compaction.Trigger + ")"
+		}
+		chart.Markers = append(chart.Markers, chart.barMarker(compaction.Turn, label))
+	}
+	return chart
+}
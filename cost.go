@@ -0,0 +1,315 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ModelPricing is the price of a model in US dollars per million tokens
+type ModelPricing struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheWrite float64 `json:"cache_write"`
+	CacheRead  float64 `json:"cache_read"`
+}
+
+// pricing maps model name prefixes to their prices. The longest matching
+// prefix wins, so dated model IDs such as claude-sonnet-4-20250514 resolve
+// to their family. Entries can be overridden from the config file.
+var pricing = map[string]ModelPricing{
+	"claude-opus-4-5":   {Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.50},
+	"claude-opus-4":     {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50},
+	"claude-sonnet-4":   {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+	"claude-haiku-4-5":  {Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.10},
+	"claude-3-opus":     {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50},
+	"claude-3-7-sonnet": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+	"claude-3-5-sonnet": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+	"claude-3-5-haiku":  {Input: 0.80, Output: 4, CacheWrite: 1, CacheRead: 0.08},
+	"claude-3-haiku":    {Input: 0.25, Output: 1.25, CacheWrite: 0.30, CacheRead: 0.03},
+}
+
+// applyPricingOverrides replaces or adds pricing entries from the config file
+func applyPricingOverrides(overrides map[string]ModelPricing) {
+	for model, price := range overrides {
+		pricing[model] = price
+	}
+}
+
+// lookupPricing returns the pricing for a model by longest prefix match
+func lookupPricing(model string) (ModelPricing, bool) {
+	best := ""
+	for prefix := range pricing {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return ModelPricing{}, false
+	}
+	return pricing[best], true
+}
+
+// usageCost converts token usage to dollars. ok is false when the model has
+// no known pricing.
+func usageCost(model string, usage Usage) (float64, bool) {
+	price, ok := lookupPricing(model)
+	if !ok {
+		return 0, false
+	}
+	cost := float64(usage.InputTokens)*price.Input +
+		float64(usage.OutputTokens)*price.Output +
+		float64(usage.CacheCreationInputTokens)*price.CacheWrite +
+		float64(usage.CacheReadInputTokens)*price.CacheRead
+	return cost / 1e6, true
+}
+
+// messageCost returns the cost of a single assistant message
+func messageCost(message Message) (float64, bool) {
+	if message.Usage == nil || message.Model == nil {
+		return 0, false
+	}
+	return usageCost(*message.Model, *message.Usage)
+}
+
+// UsageTotals sums token usage and cost over a set of API responses
+type UsageTotals struct {
+	Messages            int
+	InputTokens         int
+	OutputTokens        int
+	CacheCreationTokens int
+	CacheReadTokens     int
+	Cost                float64
+	// UnpricedTokens counts tokens of models missing from the pricing table
+	UnpricedTokens int
+}
+
+// TotalTokens returns the number of tokens of every class
+func (u UsageTotals) TotalTokens() int {
+	return u.InputTokens + u.OutputTokens + u.CacheCreationTokens + u.CacheReadTokens
+}
+
+func (u *UsageTotals) add(model string, usage Usage) {
+	u.Messages++
+	u.InputTokens += usage.InputTokens
+	u.OutputTokens += usage.OutputTokens
+	u.CacheCreationTokens += usage.CacheCreationInputTokens
+	u.CacheReadTokens += usage.CacheReadInputTokens
+	if cost, ok := usageCost(model, usage); ok {
+		u.Cost += cost
+	} else if model != "<synthetic>" {
+		u.UnpricedTokens += usage.InputTokens + usage.OutputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+	}
+}
+
+// Merge adds another set of totals to these
+func (u *UsageTotals) Merge(other UsageTotals) {
+	u.Messages += other.Messages
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CacheCreationTokens += other.CacheCreationTokens
+	u.CacheReadTokens += other.CacheReadTokens
+	u.Cost += other.Cost
+	u.UnpricedTokens += other.UnpricedTokens
+}
+
+// ModelUsage is the usage of one model within a session
+type ModelUsage struct {
+	Model string
+	UsageTotals
+}
+
+// SessionUsage is the usage of a session, in total and by model
+type SessionUsage struct {
+	UsageTotals
+	Models []ModelUsage
+}
+
+// usageRecord is one API response found in a session
+type usageRecord struct {
+	MessageID string
+	EntryUUID string
+	Model     string
+	Timestamp time.Time
+	Sidechain bool
+	// Chain is the UUID of the first entry of the subagent conversation the
+	// response belongs to, or empty for the main conversation
+	Chain     string
+	GitBranch string
+	// StopReason is empty for responses that were still streaming
+	StopReason string
+	Usage      Usage
+}
+
+// usageRecords returns the usage of every API response in a session. Claude
+// Code logs one entry per content block of a response, each repeating the
+// usage, so records are deduplicated by message ID keeping the last one.
+func usageRecords(entries []LogEntry) []usageRecord {
+	var records []usageRecord
+	index := make(map[string]int)
+	chains := make(map[string]string)
+
+	for _, entry := range entries {
+		chain := ""
+		if entry.IsSidechain {
+			// A subagent starts a new chain without a parent; its later
+			// entries inherit the chain of their parent
+			chain = entry.Uuid
+			if root, ok := chains[entry.ParentUuid]; ok && entry.ParentUuid != "" {
+				chain = root
+			}
+			chains[entry.Uuid] = chain
+		}
+		message := entry.Message
+		if message.Usage == nil || message.Model == nil {
+			continue
+		}
+		record := usageRecord{
+			EntryUUID: entry.Uuid,
+			Model:     *message.Model,
+			Timestamp: entry.Timestamp,
+			Sidechain: entry.IsSidechain,
+			Chain:     chain,
+			GitBranch: entry.GitBranch,
+			Usage:     *message.Usage,
+		}
+		if message.StopReason != nil {
+			record.StopReason = *message.StopReason
+		}
+		if message.Id != nil {
+			record.MessageID = *message.Id
+			if i, ok := index[record.MessageID]; ok {
+				records[i].Usage = record.Usage
+				if record.StopReason != "" {
+					records[i].StopReason = record.StopReason
+				}
+				continue
+			}
+			index[record.MessageID] = len(records)
+		}
+		records = append(records, record)
+	}
+
+	return records
+}
+
+// summarizeUsage totals usage records overall and per model
+func summarizeUsage(records []usageRecord) SessionUsage {
+	var usage SessionUsage
+	byModel := make(map[string]*UsageTotals)
+
+	for _, record := range records {
+		usage.add(record.Model, record.Usage)
+		totals, ok := byModel[record.Model]
+		if !ok {
+			totals = &UsageTotals{}
+			byModel[record.Model] = totals
+		}
+		totals.add(record.Model, record.Usage)
+	}
+
+	for model, totals := range byModel {
+		usage.Models = append(usage.Models, ModelUsage{Model: model, UsageTotals: *totals})
+	}
+	sort.Slice(usage.Models, func(i, j int) bool {
+		if usage.Models[i].Cost != usage.Models[j].Cost {
+			return usage.Models[i].Cost > usage.Models[j].Cost
+		}
+		return usage.Models[i].Model < usage.Models[j].Model
+	})
+	return usage
+}
+
+// sessionUsage returns the usage and cost of a parsed session
+func sessionUsage(entries []LogEntry) SessionUsage {
+	return summarizeUsage(usageRecords(entries))
+}
+
+// uniqueRecords returns the usage records of each session without the
+// responses an older session already had. Resumed sessions copy earlier
+// responses into their own file, so totals across sessions count each message
+// ID once, in the session that was written first.
+func uniqueRecords(sessions []SessionInfo) [][]usageRecord {
+	unique := make([][]usageRecord, len(sessions))
+	seen := make(map[string]bool)
+	for _, i := range sessionsByAge(sessions) {
+		for _, record := range sessions[i].records {
+			if record.MessageID != "" {
+				if seen[record.MessageID] {
+					continue
+				}
+				seen[record.MessageID] = true
+			}
+			unique[i] = append(unique[i], record)
+		}
+	}
+	return unique
+}
+
+// sessionsByAge returns the indexes of sessions in the order their files were
+// last written, oldest first
+func sessionsByAge(sessions []SessionInfo) []int {
+	order := make([]int, len(sessions))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return sessions[order[a]].ModTime.Before(sessions[order[b]].ModTime)
+	})
+	return order
+}
+
+// loadedSessionInfos returns the session metadata of loaded sessions
+func loadedSessionInfos(sessions []LoadedSession) []SessionInfo {
+	infos := make([]SessionInfo, len(sessions))
+	for i, session := range sessions {
+		infos[i] = session.Session
+	}
+	return infos
+}
+
+// sessionsUsage totals the usage of a set of sessions, counting responses
+// shared by resumed sessions once
+func sessionsUsage(sessions []SessionInfo) UsageTotals {
+	var totals UsageTotals
+	for _, records := range uniqueRecords(sessions) {
+		for _, record := range records {
+			totals.add(record.Model, record.Usage)
+		}
+	}
+	return totals
+}
+
+// projectUsage totals the usage of every session in a project
+func projectUsage(project ProjectInfo) UsageTotals {
+	return sessionsUsage(project.Sessions)
+}
+
+// projectsUsage totals the usage of a set of projects
+func projectsUsage(projects []ProjectInfo) UsageTotals {
+	var sessions []SessionInfo
+	for _, project := range projects {
+		sessions = append(sessions, project.Sessions...)
+	}
+	return sessionsUsage(sessions)
+}
+
+// formatCost formats a dollar amount, keeping precision for small amounts
+func formatCost(cost float64) string {
+	if cost > 0 && cost < 0.01 {
+		return fmt.Sprintf("$%.4f", cost)
+	}
+	return fmt.Sprintf("$%.2f", cost)
+}
+
+// formatTokens formats a token count compactly, e.g. 1.2M or 35.4K
+func formatTokens(tokens int) string {
+	switch {
+	case tokens >= 1000000:
+		return fmt.Sprintf("%.1fM", float64(tokens)/1e6)
+	case tokens >= 1000:
+		return fmt.Sprintf("%.1fK", float64(tokens)/1e3)
+	}
+	return fmt.Sprintf("%d", tokens)
+}
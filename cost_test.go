@@ -0,0 +1,93 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUniqueRecords(t *testing.T) {
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	session := func(minutes int, ids ...string) SessionInfo {
+		info := SessionInfo{ModTime: base.Add(time.Duration(minutes) * time.Minute)}
+		for _, id := range ids {
+			info.records = append(info.records, usageRecord{MessageID: id})
+		}
+		return info
+	}
+
+	tests := []struct {
+		name     string
+		sessions []SessionInfo
+		// want lists the message IDs kept for each session
+		want [][]string
+	}{
+		{
+			"no sessions",
+			nil,
+			[][]string{},
+		},
+		{
+			"distinct sessions keep everything",
+			[]SessionInfo{session(0, "a", "b"), session(5, "c")},
+			[][]string{{"a", "b"}, {"c"}},
+		},
+		{
+			"resumed session drops the copied responses",
+			[]SessionInfo{session(0, "a", "b"), session(10, "a", "b", "c")},
+			[][]string{{"a", "b"}, {"c"}},
+		},
+		{
+			"the older file keeps the response whatever the listing order",
+			[]SessionInfo{session(10, "a", "b", "c"), session(0, "a", "b")},
+			[][]string{{"c"}, {"a", "b"}},
+		},
+		{
+			"copy of a whole session counts once",
+			[]SessionInfo{session(0, "a", "b"), session(0, "a", "b")},
+			[][]string{{"a", "b"}, nil},
+		},
+		{
+			"responses without an ID are always kept",
+			[]SessionInfo{session(0, "", "a"), session(5, "", "a")},
+			[][]string{{"", "a"}, {""}},
+		},
+		{
+			"repeats within a session count once",
+			[]SessionInfo{session(0, "a", "a", "b")},
+			[][]string{{"a", "b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := [][]string{}
+			for _, records := range uniqueRecords(tt.sessions) {
+				var ids []string
+				for _, record := range records {
+					ids = append(ids, record.MessageID)
+				}
+				got = append(got, ids)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("uniqueRecords() kept %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionsUsageCountsSharedResponsesOnce(t *testing.T) {
+	usage := Usage{InputTokens: 100, OutputTokens: 10}
+	record := func(id string) usageRecord {
+		return usageRecord{MessageID: id, Model: "claude-sonnet-4-20250514", Usage: usage}
+	}
+	original := SessionInfo{ModTime: time.Unix(100, 0), records: []usageRecord{record("a"), record("b")}}
+	resumed := SessionInfo{ModTime: time.Unix(200, 0), records: []usageRecord{record("a"), record("b"), record("c")}}
+
+	totals := sessionsUsage([]SessionInfo{resumed, original})
+	if totals.Messages != 3 {
+		t.Errorf("Messages = %d, want 3", totals.Messages)
+	}
+	if want := 3 * usage.InputTokens; totals.InputTokens != want {
+		t.Errorf("InputTokens = %d, want %d", totals.InputTokens, want)
+	}
+}
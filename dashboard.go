@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const defaultDashboardDays = 30
+
+// UsageBucket aggregates usage over one day or week
+type UsageBucket struct {
+	Start time.Time
+	UsageTotals
+	Sessions  int
+	ToolCalls int
+}
+
+// ProjectUsageRow aggregates usage of one project within the dashboard range
+type ProjectUsageRow struct {
+	Project ProjectInfo
+	UsageTotals
+	Sessions  int
+	ToolCalls int
+}
+
+// Dashboard is the usage of every session within a date range
+type Dashboard struct {
+	From        time.Time
+	To          time.Time
+	Granularity string
+	Buckets     []UsageBucket
+	Projects    []ProjectUsageRow
+	Totals      UsageTotals
+	Sessions    int
+	ToolCalls   int
+}
+
+// dashboardRange parses the from and to dates of the dashboard, defaulting
+// to the last 30 days. to is exclusive and falls on the day after the last
+// day shown.
+func dashboardRange(fromValue, toValue string, now time.Time) (time.Time, time.Time) {
+	today := startOfDay(now)
+	to := today.AddDate(0, 0, 1)
+	if t, err := time.ParseInLocation("2006-01-02", toValue, now.Location()); err == nil {
+		to = t.AddDate(0, 0, 1)
+	}
+	from := to.AddDate(0, 0, -defaultDashboardDays)
+	if t, err := time.ParseInLocation("2006-01-02", fromValue, now.Location()); err == nil {
+		from = t
+	}
+	if !from.Before(to) {
+		from = to.AddDate(0, 0, -1)
+	}
+	return from, to
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// startOfWeek returns the Monday starting the week of t
+func startOfWeek(t time.Time) time.Time {
+	day := startOfDay(t)
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// getDashboard aggregates usage across every session
+func getDashboard(ctx context.Context, from, to time.Time, granularity string) (Dashboard, error) {
+	sessions, err := loadAllSessions(ctx)
+	if err != nil {
+		return Dashboard{}, err
+	}
+	return buildDashboard(sessions, from, to, granularity), nil
+}
+
+func buildDashboard(sessions []LoadedSession, from, to time.Time, granularity string) Dashboard {
+	if granularity != "week" {
+		granularity = "day"
+	}
+	dashboard := Dashboard{From: from, To: to, Granularity: granularity}
+
+	bucketStart := startOfDay
+	step := func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	if granularity == "week" {
+		bucketStart = startOfWeek
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+	}
+
+	// Every bucket in the range is shown, including empty ones
+	index := make(map[time.Time]int)
+	for start := bucketStart(from); start.Before(to); start = step(start) {
+		index[start] = len(dashboard.Buckets)
+		dashboard.Buckets = append(dashboard.Buckets, UsageBucket{Start: start})
+	}
+	inRange := func(t time.Time) bool {
+		return !t.Before(from) && t.Before(to)
+	}
+
+	projects := make(map[string]*ProjectUsageRow)
+	var projectOrder []string
+
+	records := uniqueRecords(loadedSessionInfos(sessions))
+	calls := uniqueToolCalls(sessions)
+	for s, session := range sessions {
+		activeBuckets := make(map[int]bool)
+		var sessionTotals UsageTotals
+		toolCalls := 0
+
+		for _, record := range records[s] {
+			t := record.Timestamp.In(from.Location())
+			if !inRange(t) {
+				continue
+			}
+			i := index[bucketStart(t)]
+			dashboard.Buckets[i].add(record.Model, record.Usage)
+			sessionTotals.add(record.Model, record.Usage)
+			activeBuckets[i] = true
+		}
+		for _, call := range calls[s] {
+			t := call.UseTime.In(from.Location())
+			if !inRange(t) {
+				continue
+			}
+			i := index[bucketStart(t)]
+			dashboard.Buckets[i].ToolCalls++
+			toolCalls++
+			activeBuckets[i] = true
+		}
+		if len(activeBuckets) == 0 {
+			continue
+		}
+		for i := range activeBuckets {
+			dashboard.Buckets[i].Sessions++
+		}
+
+		dashboard.Totals.Merge(sessionTotals)
+		dashboard.Sessions++
+		dashboard.ToolCalls += toolCalls
+
+		key := session.Project.Root.ID + "/" + session.Project.Name
+		row, ok := projects[key]
+		if !ok {
+			row = &ProjectUsageRow{Project: session.Project}
+			projects[key] = row
+			projectOrder = append(projectOrder, key)
+		}
+		row.Merge(sessionTotals)
+		row.Sessions++
+		row.ToolCalls += toolCalls
+	}
+
+	for _, key := range projectOrder {
+		dashboard.Projects = append(dashboard.Projects, *projects[key])
+	}
+	sort.SliceStable(dashboard.Projects, func(i, j int) bool {
+		if dashboard.Projects[i].Cost != dashboard.Projects[j].Cost {
+			return dashboard.Projects[i].Cost > dashboard.Projects[j].Cost
+		}
+		return dashboard.Projects[i].TotalTokens() > dashboard.Projects[j].TotalTokens()
+	})
+
+	return dashboard
+}
+
+// bucketLabel formats the start of a bucket for chart axes
+func (d Dashboard) bucketLabel(bucket UsageBucket) string {
+	if d.Granularity == "week" {
+		return "Wk " + bucket.Start.Format("Jan 2")
+	}
+	return bucket.Start.Format("Jan 2")
+}
+
+// dashboardPresetURL links to the dashboard for the last n days
+func dashboardPresetURL(days int, granularity string) string {
+	to := startOfDay(time.Now())
+	from := to.AddDate(0, 0, 1-days)
+	return fmt.Sprintf("/dashboard?from=%s&to=%s&by=%s", from.Format("2006-01-02"), to.Format("2006-01-02"), granularity)
+}
+
+// LastDay returns the last day included in the range
+func (d Dashboard) LastDay() time.Time {
+	return d.To.AddDate(0, 0, -1)
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// maxChurnSessions is the number of sessions linked from each file
+const maxChurnSessions = 5
+
+// FileChurn counts how often a project's sessions read and changed a file
+type FileChurn struct {
+	Path         string
+	Reads        int
+	Edits        int
+	Writes       int
+	LinesAdded   int
+	LinesRemoved int
+	Sessions     []SessionInfo
+}
+
+// LinesChanged returns the lines added plus the lines removed
+func (c FileChurn) LinesChanged() int {
+	return c.LinesAdded + c.LinesRemoved
+}
+
+// Touches returns the number of tool calls on the file
+func (c FileChurn) Touches() int {
+	return c.Reads + c.Edits + c.Writes
+}
+
+func (c *FileChurn) merge(other FileChurn) {
+	c.Reads += other.Reads
+	c.Edits += other.Edits
+	c.Writes += other.Writes
+	c.LinesAdded += other.LinesAdded
+	c.LinesRemoved += other.LinesRemoved
+}
+
+// ChurnNode is a directory or file in the churn tree. Directories hold the
+// totals of everything below them.
+type ChurnNode struct {
+	Name     string
+	Path     string
+	Churn    FileChurn
+	Children []*ChurnNode
+}
+
+// IsDir reports whether the node is a directory
+func (n *ChurnNode) IsDir() bool {
+	return len(n.Children) > 0
+}
+
+// ProjectChurn is the file activity of every session of a project
+type ProjectChurn struct {
+	Project ProjectInfo
+	Sort    string
+	Files   int
+	Total   FileChurn
+	Tree    []*ChurnNode
+
+	// top is the busiest file's value, the scale of the heatmap
+	top int
+}
+
+// churnSorts are the ways the churn tree can be ordered
+var churnSorts = []string{"edits", "lines", "reads"}
+
+// getProjectChurn parses every session of a project and aggregates the
+// files its tools read, edited and wrote
+func getProjectChurn(ctx context.Context, rootID, projectName, sortBy string) (ProjectChurn, error) {
+	project, err := getProject(ctx, rootID, projectName)
+	if err != nil {
+		return ProjectChurn{}, err
+	}
+	sessions, err := loadProjectSessions(ctx, project)
+	if err != nil {
+		return ProjectChurn{}, err
+	}
+	return buildProjectChurn(project, sessions, sortBy), nil
+}
+
+func buildProjectChurn(project ProjectInfo, sessions []LoadedSession, sortBy string) ProjectChurn {
+	churn := ProjectChurn{Project: project, Sort: "edits"}
+	for _, s := range churnSorts {
+		if s == sortBy {
+			churn.Sort = s
+		}
+	}
+
+	files := make(map[string]*FileChurn)
+	for _, session := range sessions {
+		seen := make(map[string]bool)
+		for _, call := range pairToolCalls(session.Entries) {
+			operation := fileOperation(call.Use.Name)
+			filePath := toolInputFilePath(call.Use.Input)
+			if operation == "" || filePath == "" || call.IsError() {
+				continue
+			}
+			file := files[filePath]
+			if file == nil {
+				file = &FileChurn{Path: filePath}
+				files[filePath] = file
+			}
+			switch operation {
+			case "read":
+				file.Reads++
+			case "edit":
+				file.Edits++
+			case "write":
+				file.Writes++
+			}
+			added, removed := toolLineChanges(call.Use.Input)
+			file.LinesAdded += added
+			file.LinesRemoved += removed
+			if !seen[filePath] {
+				seen[filePath] = true
+				file.Sessions = append(file.Sessions, session.Session)
+			}
+		}
+	}
+
+	root := &ChurnNode{}
+	for _, file := range files {
+		churn.Files++
+		churn.Total.merge(*file)
+		if v := churnValue(*file, churn.Sort); v > churn.top {
+			churn.top = v
+		}
+		insertChurnFile(root, projectRelativePath(project, file.Path), *file)
+	}
+	compactChurnTree(root)
+	sortChurnTree(root, churn.Sort)
+	churn.Tree = root.Children
+	return churn
+}
+
+// toolLineChanges counts the lines an Edit, MultiEdit or Write call added
+// and removed. Edits only count the lines that differ between the old and
+// new strings; Writes count the whole content as added.
+func toolLineChanges(input ToolInput) (int, int) {
+	switch in := input.(type) {
+	case EditInput:
+		return editLineChanges(in.OldString, in.NewString)
+	case MultiEditInput:
+		added, removed := 0, 0
+		for _, edit := range in.Edits {
+			a, r := editLineChanges(edit.OldString, edit.NewString)
+			added += a
+			removed += r
+		}
+		return added, removed
+	case WriteInput:
+		return countLines(in.Content), 0
+	}
+	return 0, 0
+}
+
+// editLineChanges trims the lines shared at the start and end of an edit
+// and counts what remains on either side
+func editLineChanges(oldString, newString string) (int, int) {
+	oldLines := strings.Split(oldString, "\n")
+	newLines := strings.Split(newString, "\n")
+	if oldString == "" {
+		oldLines = nil
+	}
+	if newString == "" {
+		newLines = nil
+	}
+	for len(oldLines) > 0 && len(newLines) > 0 && oldLines[0] == newLines[0] {
+		oldLines, newLines = oldLines[1:], newLines[1:]
+	}
+	for len(oldLines) > 0 && len(newLines) > 0 && oldLines[len(oldLines)-1] == newLines[len(newLines)-1] {
+		oldLines, newLines = oldLines[:len(oldLines)-1], newLines[:len(newLines)-1]
+	}
+	return len(newLines), len(oldLines)
+}
+
+func insertChurnFile(root *ChurnNode, filePath string, file FileChurn) {
+	node := root
+	node.Churn.merge(file)
+	parts := strings.Split(strings.TrimPrefix(filePath, "/"), "/")
+	for i, part := range parts {
+		var child *ChurnNode
+		for _, c := range node.Children {
+			if c.Name == part {
+				child = c
+				break
+			}
+		}
+		if child == nil {
+			child = &ChurnNode{Name: part, Path: path.Join(parts[:i+1]...)}
+			// Files outside the project keep their absolute paths
+			if strings.HasPrefix(filePath, "/") {
+				child.Path = "/" + child.Path
+				if i == 0 {
+					child.Name = "/" + part
+				}
+			}
+			node.Children = append(node.Children, child)
+		}
+		if i == len(parts)-1 {
+			child.Churn = file
+		} else {
+			child.Churn.merge(file)
+		}
+		node = child
+	}
+}
+
+// compactChurnTree merges directories that contain a single directory, so
+// deep paths such as src/main/java collapse into one row
+func compactChurnTree(node *ChurnNode) {
+	for _, child := range node.Children {
+		for len(child.Children) == 1 && child.Children[0].IsDir() {
+			only := child.Children[0]
+			child.Name += "/" + only.Name
+			child.Path = only.Path
+			child.Children = only.Children
+		}
+		compactChurnTree(child)
+	}
+}
+
+func sortChurnTree(node *ChurnNode, sortBy string) {
+	key := func(c FileChurn) int { return churnValue(c, sortBy) }
+	sort.Slice(node.Children, func(i, j int) bool {
+		a, b := node.Children[i], node.Children[j]
+		if key(a.Churn) != key(b.Churn) {
+			return key(a.Churn) > key(b.Churn)
+		}
+		if a.Churn.Touches() != b.Churn.Touches() {
+			return a.Churn.Touches() > b.Churn.Touches()
+		}
+		return a.Name < b.Name
+	})
+	for _, child := range node.Children {
+		sortChurnTree(child, sortBy)
+	}
+}
+
+// churnValue is what the tree is sorted and coloured by
+func churnValue(c FileChurn, sortBy string) int {
+	switch sortBy {
+	case "reads":
+		return c.Reads
+	case "lines":
+		return c.LinesChanged()
+	}
+	return c.Edits + c.Writes
+}
+
+// churnHeat returns a background colour whose intensity is a node's share
+// of the busiest file in the current sort order
+func (p ProjectChurn) churnHeat(c FileChurn) string {
+	value := churnValue(c, p.Sort)
+	if p.top == 0 || value == 0 {
+		return "background: transparent"
+	}
+	share := float64(value) / float64(p.top)
+	if share > 1 {
+		share = 1
+	}
+	return fmt.Sprintf("background: rgba(239, 68, 68, %.2f)", 0.08+0.5*share)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"sort"
+)
+
+// FileTouch is a single tool call that read, wrote or edited a file
+type FileTouch struct {
+	Project   ProjectInfo
+	Session   SessionInfo
+	Call      ToolCall
+	FilePath  string
+	Operation string
+}
+
+// FileSessionSummary counts the touches of a file within one session
+type FileSessionSummary struct {
+	Project ProjectInfo
+	Session SessionInfo
+	Reads   int
+	Edits   int
+	Writes  int
+}
+
+// fileOperation classifies a tool by what it does to a file
+func fileOperation(toolName string) string {
+	switch toolName {
+	case "Read":
+		return "read"
+	case "Write":
+		return "write"
+	case "Edit", "MultiEdit":
+		return "edit"
+	}
+	return ""
+}
+
+// getFileHistory returns every tool call across all sessions that operated
+// on a file, oldest first. The path matches exactly, by suffix or as a glob.
+func getFileHistory(ctx context.Context, filePath string) ([]FileTouch, error) {
+	sessions, err := loadAllSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var touches []FileTouch
+	for _, session := range sessions {
+		for _, call := range pairToolCalls(session.Entries) {
+			operation := fileOperation(call.Use.Name)
+			touchedPath := toolInputFilePath(call.Use.Input)
+			if operation == "" || touchedPath == "" || !matchAnyFile([]string{filePath}, touchedPath) {
+				continue
+			}
+			touches = append(touches, FileTouch{
+				Project:   session.Project,
+				Session:   session.Session,
+				Call:      call,
+				FilePath:  touchedPath,
+				Operation: operation,
+			})
+		}
+	}
+
+	sort.SliceStable(touches, func(i, j int) bool {
+		return touches[i].Call.UseTime.Before(touches[j].Call.UseTime)
+	})
+	return touches, nil
+}
+
+// summarizeFileSessions lists the sessions in a file history in the order
+// they first touched the file
+func summarizeFileSessions(touches []FileTouch) []FileSessionSummary {
+	var summaries []FileSessionSummary
+	index := make(map[string]int)
+
+	for _, touch := range touches {
+		key := touch.Project.Root.ID + "/" + touch.Project.Name + "/" + touch.Session.UUID
+		i, ok := index[key]
+		if !ok {
+			i = len(summaries)
+			index[key] = i
+			summaries = append(summaries, FileSessionSummary{Project: touch.Project, Session: touch.Session})
+		}
+		switch touch.Operation {
+		case "read":
+			summaries[i].Reads++
+		case "edit":
+			summaries[i].Edits++
+		case "write":
+			summaries[i].Writes++
+		}
+	}
+	return summaries
+}
+
+// distinctFilePaths returns the distinct paths in a file history, which can
+// be more than one when a suffix or glob was given
+func distinctFilePaths(touches []FileTouch) []string {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, touch := range touches {
+		if !seen[touch.FilePath] {
+			seen[touch.FilePath] = true
+			paths = append(paths, touch.FilePath)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kinds of friction, where the user or the API stopped the agent
+const (
+	frictionInterrupt = "interrupt"
+	frictionRejected  = "rejected"
+	frictionAPIError  = "api-error"
+)
+
+const maxFrictionEvents = 50
+
+var apiErrorStatusPattern = regexp.MustCompile(`^API Error: (\d{3})`)
+
+// rejectionPrefix starts the tool results Claude Code writes when the user
+// declines a permission prompt. Interrupting a running tool writes "The user
+// doesn't want to take this action right now" instead, followed by an
+// interruption entry, so that result is left to the interruption.
+const rejectionPrefix = "The user doesn't want to proceed with this tool use"
+
+// FrictionEvent is an interruption, a rejected tool call or an API error
+type FrictionEvent struct {
+	Project   ProjectInfo
+	Session   SessionInfo
+	Kind      string
+	EntryUUID string
+	Timestamp time.Time
+	// Label is the tool that was rejected, the kind of interruption or the
+	// class of API error
+	Label string
+	// Detail is the feedback given with a rejection or the API error text
+	Detail string
+	// toolUseID is the rejected tool call
+	toolUseID string
+}
+
+// FrictionCounts counts friction events, overall or for a project
+type FrictionCounts struct {
+	Project       ProjectInfo
+	Sessions      int
+	ToolCalls     int
+	Interruptions int
+	Rejections    int
+	APIErrors     int
+}
+
+// Total returns the number of friction events
+func (c FrictionCounts) Total() int {
+	return c.Interruptions + c.Rejections + c.APIErrors
+}
+
+// PerSession returns the average number of friction events per session
+func (c FrictionCounts) PerSession() float64 {
+	if c.Sessions == 0 {
+		return 0
+	}
+	return float64(c.Total()) / float64(c.Sessions)
+}
+
+// RejectionRate returns the share of tool calls the user rejected
+func (c FrictionCounts) RejectionRate() float64 {
+	if c.ToolCalls == 0 {
+		return 0
+	}
+	return float64(c.Rejections) / float64(c.ToolCalls)
+}
+
+func (c *FrictionCounts) count(kind string) {
+	switch kind {
+	case frictionInterrupt:
+		c.Interruptions++
+	case frictionRejected:
+		c.Rejections++
+	case frictionAPIError:
+		c.APIErrors++
+	}
+}
+
+// LabelCount counts the events of one kind with the same label
+type LabelCount struct {
+	Label string
+	Count int
+}
+
+// FrictionReport aggregates friction across sessions
+type FrictionReport struct {
+	Totals        FrictionCounts
+	Projects      []FrictionCounts
+	RejectedTools []LabelCount
+	Interruptions []LabelCount
+	APIErrors     []LabelCount
+	Events        []FrictionEvent
+}
+
+// entryFriction classifies an entry as an interruption, a rejection or an
+// API error, returning the kind and label, or empty strings
+func entryFriction(entry LogEntry) (string, string) {
+	if entry.IsApiErrorMessage {
+		return frictionAPIError, apiErrorLabel(entryText(entry))
+	}
+	if entry.Type != "user" {
+		return "", ""
+	}
+	if blocks, ok := entry.Message.Content.([]ContentBlock); ok {
+		for _, block := range blocks {
+			if result, ok := block.(*ToolResultBlock); ok && isRejection(toolResultText(result.Content)) {
+				return frictionRejected, ""
+			}
+		}
+	}
+	text := strings.TrimSpace(entryText(entry))
+	if strings.HasPrefix(text, "[Request interrupted by user for tool use]") {
+		return frictionInterrupt, "During tool use"
+	}
+	if strings.HasPrefix(text, "[Request interrupted by user") {
+		return frictionInterrupt, "During response"
+	}
+	return "", ""
+}
+
+// sessionFriction lists the friction events of a session in order. Rejected
+// tool calls are labelled with the tool that was declined.
+func sessionFriction(session LoadedSession) []FrictionEvent {
+	calls := toolCallsByID(pairToolCalls(session.Entries))
+
+	var events []FrictionEvent
+	for _, entry := range session.Entries {
+		kind, label := entryFriction(entry)
+		if kind == "" {
+			continue
+		}
+		event := FrictionEvent{
+			Project:   session.Project,
+			Session:   session.Session,
+			Kind:      kind,
+			EntryUUID: entry.Uuid,
+			Timestamp: entry.Timestamp,
+			Label:     label,
+		}
+		switch kind {
+		case frictionAPIError:
+			event.Detail = strings.TrimSpace(entryText(entry))
+		case frictionRejected:
+			for _, block := range entry.Message.Content.([]ContentBlock) {
+				result, ok := block.(*ToolResultBlock)
+				if !ok || !isRejection(toolResultText(result.Content)) {
+					continue
+				}
+				if call, ok := calls[result.ToolUseId]; ok {
+					event.Label = call.Use.Name
+				}
+				event.toolUseID = result.ToolUseId
+				event.Detail = rejectionFeedback(toolResultText(result.Content))
+				events = append(events, event)
+			}
+			continue
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// buildFrictionReport aggregates the friction of every session per project
+// and per tool, error class and kind of interruption
+func buildFrictionReport(sessions []LoadedSession) FrictionReport {
+	var report FrictionReport
+	projects := make(map[string]*FrictionCounts)
+	var order []string
+	rejected := make(map[string]int)
+	interruptions := make(map[string]int)
+	apiErrors := make(map[string]int)
+
+	// Resumed sessions copy earlier entries into their own file, so events
+	// and tool calls are counted once, in the session written first
+	calls := uniqueToolCalls(sessions)
+	seen := make(map[string]bool)
+	for _, s := range sessionsByAge(loadedSessionInfos(sessions)) {
+		session := sessions[s]
+		key := session.Project.Root.ID + "/" + session.Project.Name
+		project := projects[key]
+		if project == nil {
+			project = &FrictionCounts{Project: session.Project}
+			projects[key] = project
+			order = append(order, key)
+		}
+		project.Sessions++
+		project.ToolCalls += len(calls[s])
+		report.Totals.Sessions++
+		report.Totals.ToolCalls += len(calls[s])
+
+		for _, event := range sessionFriction(session) {
+			if id := frictionEventID(event); id != "" {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+			}
+			project.count(event.Kind)
+			report.Totals.count(event.Kind)
+			switch event.Kind {
+			case frictionRejected:
+				rejected[orUnknown(event.Label)]++
+			case frictionInterrupt:
+				interruptions[event.Label]++
+			case frictionAPIError:
+				apiErrors[event.Label]++
+			}
+			report.Events = append(report.Events, event)
+		}
+	}
+
+	for _, key := range order {
+		if projects[key].Total() > 0 {
+			report.Projects = append(report.Projects, *projects[key])
+		}
+	}
+	sort.SliceStable(report.Projects, func(i, j int) bool {
+		return report.Projects[i].Total() > report.Projects[j].Total()
+	})
+	report.RejectedTools = sortedLabels(rejected)
+	report.Interruptions = sortedLabels(interruptions)
+	report.APIErrors = sortedLabels(apiErrors)
+
+	sort.SliceStable(report.Events, func(i, j int) bool {
+		return report.Events[i].Timestamp.After(report.Events[j].Timestamp)
+	})
+	if len(report.Events) > maxFrictionEvents {
+		report.Events = report.Events[:maxFrictionEvents]
+	}
+	return report
+}
+
+// sessionFrictionReport describes the friction of a single parsed session
+func sessionFrictionReport(entries []LogEntry) FrictionReport {
+	return buildFrictionReport([]LoadedSession{{Entries: entries}})
+}
+
+// getFrictionReport aggregates friction across every session
+func getFrictionReport(ctx context.Context) (FrictionReport, error) {
+	sessions, err := loadAllSessions(ctx)
+	if err != nil {
+		return FrictionReport{}, err
+	}
+	return buildFrictionReport(sessions), nil
+}
+
+// frictionEventID identifies an event across the sessions it was copied
+// into: a rejection by its tool call, anything else by its entry
+func frictionEventID(event FrictionEvent) string {
+	if event.toolUseID != "" {
+		return "tool:" + event.toolUseID
+	}
+	if event.EntryUUID != "" {
+		return "entry:" + event.EntryUUID
+	}
+	return ""
+}
+
+func sortedLabels(counts map[string]int) []LabelCount {
+	var labels []LabelCount
+	for label, count := range counts {
+		labels = append(labels, LabelCount{Label: label, Count: count})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].Count != labels[j].Count {
+			return labels[i].Count > labels[j].Count
+		}
+		return labels[i].Label < labels[j].Label
+	})
+	return labels
+}
+
+func isRejection(text string) bool {
+	return strings.HasPrefix(text, rejectionPrefix)
+}
+
+// rejectionFeedback returns what the user said when rejecting a tool call
+func rejectionFeedback(text string) string {
+	if i := strings.Index(text, "the user said:"); i >= 0 {
+		return strings.TrimSpace(text[i+len("the user said:"):])
+	}
+	return ""
+}
+
+// apiErrorLabel classifies an API error message by its HTTP status or, for
+// errors without one, by its first words
+func apiErrorLabel(text string) string {
+	text = strings.TrimSpace(text)
+	if match := apiErrorStatusPattern.FindStringSubmatch(text); match != nil {
+		return "HTTP " + match[1]
+	}
+	return truncateRunes(singleLine(text), 60)
+}
+
+// entryText joins the text of a message, whether plain or in text blocks
+func entryText(entry LogEntry) string {
+	switch content := entry.Message.Content.(type) {
+	case string:
+		return content
+	case []ContentBlock:
+		var parts []string
+		for _, block := range content {
+			if text, ok := block.(*TextBlock); ok {
+				parts = append(parts, text.Text)
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
+
+// frictionKindLabel names a kind of friction for display
+func frictionKindLabel(kind string) string {
+	switch kind {
+	case frictionInterrupt:
+		return "Interrupted"
+	case frictionRejected:
+		return "Rejected"
+	case frictionAPIError:
+		return "API error"
+	}
+	return kind
+}
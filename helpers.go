@@ -3,8 +3,10 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
+	"unicode"
 	
 	"github.com/gomarkdown/markdown"
 	"github.com/gomarkdown/markdown/html"
@@ -114,27 +116,37 @@ func extractSessionUUID(filename string) string {
 	return ""
 }
 
-func extractProjectName(filename string) string {
-	// Extract project name from path like "/Users/user/.claude/projects/my-project/session.jsonl"
-	// Look for the pattern "/.claude/projects/{project-name}/"
-	
-	claudeIndex := strings.Index(filename, "/.claude/projects/")
-	if claudeIndex == -1 {
-		return ""
-	}
-	
-	// Start after "/.claude/projects/"
-	start := claudeIndex + len("/.claude/projects/")
-	remaining := filename[start:]
-	
-	// Find the next slash to get the project name
-	if slashIndex := strings.Index(remaining, "/"); slashIndex != -1 {
-		return remaining[:slashIndex]
-	}
-	
-	// If no slash found, the remaining part might be the project name
-	// (though this shouldn't happen in normal usage)
-	return remaining
+func projectURL(rootID, projectName string) string {
+	return "/project/" + url.PathEscape(rootID) + "/" + url.PathEscape(projectName)
+}
+
+func sessionURL(rootID, projectName, sessionUUID string) string {
+	return "/session/" + url.PathEscape(rootID) + "/" + url.PathEscape(projectName) + "/" + url.PathEscape(sessionUUID)
+}
+
+// branchesURL links to the per-branch view of a project
+func branchesURL(rootID, projectName string) string {
+	return "/branches/" + url.PathEscape(rootID) + "/" + url.PathEscape(projectName)
+}
+
+// churnURL links to the file churn view of a project
+func churnURL(rootID, projectName, sortBy string) string {
+	return "/churn/" + url.PathEscape(rootID) + "/" + url.PathEscape(projectName) + "?sort=" + sortBy
+}
+
+// compareURL links to the side-by-side comparison of two sessions
+func compareURL(a, b string) string {
+	return "/compare?a=" + url.QueryEscape(a) + "&b=" + url.QueryEscape(b)
+}
+
+// fileURL links to the cross-session history of a file
+func fileURL(filePath string) string {
+	return "/file?path=" + url.QueryEscape(filePath)
+}
+
+// entryURL links to a single entry within a session page
+func entryURL(rootID, projectName, sessionUUID, entryUUID string) string {
+	return sessionURL(rootID, projectName, sessionUUID) + "#entry-" + entryUUID
 }
 
 func getSessionCwd(entries []LogEntry) string {
@@ -145,6 +157,13 @@ func getSessionCwd(entries []LogEntry) string {
 	return ""
 }
 
+func conversationPageTitle(entries []LogEntry) string {
+	if title := getSessionTitle(entries); title != "" {
+		return title + " - Claude Code Parser"
+	}
+	return "Claude Code Parser - Conversation Log"
+}
+
 func formatTime(t time.Time) string {
 	now := time.Now()
 	diff := now.Sub(t)
@@ -179,6 +198,14 @@ func formatFileSize(bytes int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
+// shortID abbreviates a UUID or request ID for display
+func shortID(id string) string {
+	if len(id) <= 8 {
+		return id
+	}
+	return id[:8] + "..."
+}
+
 func truncateText(text string, maxLen int) string {
 	if len(text) <= maxLen {
 		return text
@@ -186,6 +213,16 @@ func truncateText(text string, maxLen int) string {
 	return text[:maxLen]
 }
 
+// truncateRunes shortens text to at most max characters, ending it with an
+// ellipsis when cut
+func truncateRunes(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return strings.TrimRightFunc(string(runes[:max-1]), unicode.IsSpace) + "…"
+}
+
 func renderMarkdown(content string) string {
 	// Create markdown parser with extensions
 	extensions := parser.CommonExtensions | parser.AutoHeadingIDs | parser.NoEmptyLineBeforeBlock
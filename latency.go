@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	maxSlowCalls = 20
+	maxSlowTurns = 10
+)
+
+// latencyBuckets are the upper bounds of the latency histogram
+var latencyBuckets = []time.Duration{
+	time.Second,
+	5 * time.Second,
+	30 * time.Second,
+	2 * time.Minute,
+	10 * time.Minute,
+}
+
+// CallLatency is the time a tool call took from its tool_use to its result
+type CallLatency struct {
+	Project  ProjectInfo
+	Session  SessionInfo
+	Call     ToolCall
+	Duration time.Duration
+}
+
+// TurnLatency is the time from a user prompt to the last assistant reply
+// before the next prompt
+type TurnLatency struct {
+	Project   ProjectInfo
+	Session   SessionInfo
+	EntryUUID string
+	Prompt    string
+	Start     time.Time
+	Duration  time.Duration
+	ToolCalls int
+}
+
+// LatencyDistribution summarises a set of durations
+type LatencyDistribution struct {
+	Name      string
+	Count     int
+	Total     time.Duration
+	P50       time.Duration
+	P90       time.Duration
+	P99       time.Duration
+	Max       time.Duration
+	Histogram []int
+}
+
+// LatencyReport holds tool and turn latencies of one session or many
+type LatencyReport struct {
+	Tools     []LatencyDistribution
+	Turns     LatencyDistribution
+	Projects  []LatencyDistribution
+	SlowCalls []CallLatency
+	SlowTurns []TurnLatency
+}
+
+// sessionCallLatencies returns the duration of every completed tool call
+func sessionCallLatencies(session LoadedSession) []CallLatency {
+	var latencies []CallLatency
+	for _, call := range pairToolCalls(session.Entries) {
+		if call.Result == nil || call.UseTime.IsZero() || call.ResultTime.IsZero() {
+			continue
+		}
+		duration := call.ResultTime.Sub(call.UseTime)
+		if duration < 0 {
+			continue
+		}
+		latencies = append(latencies, CallLatency{
+			Project:  session.Project,
+			Session:  session.Session,
+			Call:     call,
+			Duration: duration,
+		})
+	}
+	return latencies
+}
+
+// sessionTurnLatencies splits the main conversation into turns at each
+// prompt typed by the user and measures how long the agent worked on each
+func sessionTurnLatencies(session LoadedSession) []TurnLatency {
+	var turns []TurnLatency
+	var current *TurnLatency
+	var last time.Time
+
+	finish := func() {
+		if current != nil && last.After(current.Start) {
+			current.Duration = last.Sub(current.Start)
+			turns = append(turns, *current)
+		}
+		current = nil
+	}
+
+	for _, entry := range session.Entries {
+		if entry.IsSidechain || entry.Timestamp.IsZero() {
+			continue
+		}
+		if entry.Type == "user" {
+			if title := promptTitle(entry); title != "" {
+				finish()
+				current = &TurnLatency{
+					Project:   session.Project,
+					Session:   session.Session,
+					EntryUUID: entry.Uuid,
+					Prompt:    title,
+					Start:     entry.Timestamp,
+				}
+			}
+			continue
+		}
+		if entry.Type == "assistant" && current != nil {
+			last = entry.Timestamp
+			if blocks, ok := entry.Message.Content.([]ContentBlock); ok {
+				for _, block := range blocks {
+					if _, ok := block.(*ToolUseBlock); ok {
+						current.ToolCalls++
+					}
+				}
+			}
+		}
+	}
+	finish()
+
+	return turns
+}
+
+// buildLatencyReport aggregates call and turn latencies across sessions
+func buildLatencyReport(sessions []LoadedSession) LatencyReport {
+	var report LatencyReport
+
+	byTool := make(map[string][]time.Duration)
+	byProject := make(map[string][]time.Duration)
+	var turnDurations []time.Duration
+
+	for _, session := range sessions {
+		for _, latency := range sessionCallLatencies(session) {
+			byTool[latency.Call.Use.Name] = append(byTool[latency.Call.Use.Name], latency.Duration)
+			report.SlowCalls = append(report.SlowCalls, latency)
+		}
+		for _, turn := range sessionTurnLatencies(session) {
+			turnDurations = append(turnDurations, turn.Duration)
+			byProject[session.Project.DisplayName] = append(byProject[session.Project.DisplayName], turn.Duration)
+			report.SlowTurns = append(report.SlowTurns, turn)
+		}
+	}
+
+	for tool, durations := range byTool {
+		report.Tools = append(report.Tools, latencyDistribution(tool, durations))
+	}
+	sort.Slice(report.Tools, func(i, j int) bool {
+		return report.Tools[i].Total > report.Tools[j].Total
+	})
+	for project, durations := range byProject {
+		report.Projects = append(report.Projects, latencyDistribution(project, durations))
+	}
+	sort.Slice(report.Projects, func(i, j int) bool {
+		return report.Projects[i].Total > report.Projects[j].Total
+	})
+	report.Turns = latencyDistribution("Turns", turnDurations)
+
+	sort.SliceStable(report.SlowCalls, func(i, j int) bool {
+		return report.SlowCalls[i].Duration > report.SlowCalls[j].Duration
+	})
+	if len(report.SlowCalls) > maxSlowCalls {
+		report.SlowCalls = report.SlowCalls[:maxSlowCalls]
+	}
+	sort.SliceStable(report.SlowTurns, func(i, j int) bool {
+		return report.SlowTurns[i].Duration > report.SlowTurns[j].Duration
+	})
+	if len(report.SlowTurns) > maxSlowTurns {
+		report.SlowTurns = report.SlowTurns[:maxSlowTurns]
+	}
+
+	return report
+}
+
+// sessionLatencyReport measures the latencies of a single parsed session
+func sessionLatencyReport(entries []LogEntry) LatencyReport {
+	return buildLatencyReport([]LoadedSession{{Entries: entries}})
+}
+
+// getLatencyReport aggregates latencies across every session
+func getLatencyReport(ctx context.Context) (LatencyReport, error) {
+	sessions, err := loadAllSessions(ctx)
+	if err != nil {
+		return LatencyReport{}, err
+	}
+	return buildLatencyReport(sessions), nil
+}
+
+func latencyDistribution(name string, durations []time.Duration) LatencyDistribution {
+	distribution := LatencyDistribution{
+		Name:      name,
+		Count:     len(durations),
+		Histogram: make([]int, len(latencyBuckets)+1),
+	}
+	if len(durations) == 0 {
+		return distribution
+	}
+
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	for _, d := range sorted {
+		distribution.Total += d
+		bucket := len(latencyBuckets)
+		for i, bound := range latencyBuckets {
+			if d < bound {
+				bucket = i
+				break
+			}
+		}
+		distribution.Histogram[bucket]++
+	}
+	distribution.P50 = percentile(sorted, 0.50)
+	distribution.P90 = percentile(sorted, 0.90)
+	distribution.P99 = percentile(sorted, 0.99)
+	distribution.Max = sorted[len(sorted)-1]
+	return distribution
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(float64(len(sorted))*p+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// latencyBucketLabels names the histogram buckets
+func latencyBucketLabels() []string {
+	labels := []string{"<1s", "1-5s", "5-30s", "30s-2m", "2-10m", ">10m"}
+	return labels[:len(latencyBuckets)+1]
+}
+
+// histogramHeight scales a histogram bin to at most 24 pixels
+func histogramHeight(count, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return count * 24 / total
+}
+
+// latencyEntryLink links to an entry, within the page for a single session
+func latencyEntryLink(project ProjectInfo, session SessionInfo, entryUUID string) string {
+	if project.Name == "" {
+		return "#entry-" + entryUUID
+	}
+	return entryURL(project.Root.ID, project.Name, session.UUID, entryUUID)
+}
+
+// formatLatency formats a duration compactly, e.g. 850ms, 12.3s or 4m05s
+func formatLatency(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// diffContext is the number of unchanged lines shown around changes
+	diffContext = 3
+	// maxDiffLines bounds the diff time, which grows with the file length
+	// times the number of differences; larger files are not diffed
+	maxDiffLines = 3000
+)
+
+// DiffLine is a line of a unified diff: Kind is ' ', '+' or '-'
+type DiffLine struct {
+	Kind byte
+	Text string
+}
+
+// DiffHunk is a run of changes with surrounding context
+type DiffHunk struct {
+	OldStart int
+	NewStart int
+	Lines    []DiffLine
+}
+
+// lineDiff compares two texts line by line. It returns false when either is
+// too long to diff.
+func lineDiff(oldText, newText string) ([]DiffHunk, bool) {
+	a := splitLines(oldText)
+	b := splitLines(newText)
+	if len(a) > maxDiffLines || len(b) > maxDiffLines {
+		return nil, false
+	}
+
+	var lines []DiffLine
+	i, j := 0, 0
+	for _, op := range editScript(a, b) {
+		switch op {
+		case ' ':
+			lines = append(lines, DiffLine{Kind: ' ', Text: a[i]})
+			i++
+			j++
+		case '-':
+			lines = append(lines, DiffLine{Kind: '-', Text: a[i]})
+			i++
+		case '+':
+			lines = append(lines, DiffLine{Kind: '+', Text: b[j]})
+			j++
+		}
+	}
+	return diffHunks(lines), true
+}
+
+// editScript returns a shortest edit script turning a into b: ' ' keeps the
+// next element of both, '-' deletes the next element of a and '+' inserts the
+// next element of b. Deletions come before insertions. It uses Myers'
+// linear-space algorithm, splitting at the middle snake of each range.
+func editScript(a, b []string) []byte {
+	script := appendEdits(make([]byte, 0, len(a)+len(b)), a, b)
+
+	// Reorder each run of changes so its deletions come first
+	for start := 0; start < len(script); {
+		if script[start] == ' ' {
+			start++
+			continue
+		}
+		end, deletions := start, 0
+		for ; end < len(script) && script[end] != ' '; end++ {
+			if script[end] == '-' {
+				deletions++
+			}
+		}
+		for k := start; k < end; k++ {
+			if k < start+deletions {
+				script[k] = '-'
+			} else {
+				script[k] = '+'
+			}
+		}
+		start = end
+	}
+	return script
+}
+
+func appendEdits(script []byte, a, b []string) []byte {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	script = appendOps(script, ' ', prefix)
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		script = appendOps(script, '+', len(b))
+	case len(b) == 0:
+		script = appendOps(script, '-', len(a))
+	default:
+		// Both ranges are non-empty and differ at both ends, so each side of
+		// the middle snake holds at least one edit and is smaller than the
+		// whole
+		x, y, u, v := middleSnake(a, b)
+		script = appendEdits(script, a[:x], b[:y])
+		script = appendOps(script, ' ', u-x)
+		script = appendEdits(script, a[u:], b[v:])
+	}
+	return appendOps(script, ' ', suffix)
+}
+
+func appendOps(script []byte, op byte, n int) []byte {
+	for ; n > 0; n-- {
+		script = append(script, op)
+	}
+	return script
+}
+
+// middleSnake finds the diagonal run from (x, y) to (u, v) in the middle of a
+// shortest edit path, searching from both ends at once. The backward search
+// works on the reversed sequences.
+func middleSnake(a, b []string) (int, int, int, int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	limit := (n + m + 1) / 2
+	offset := limit + 1
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			if c := delta - k; odd && c >= -(d-1) && c <= d-1 && x+backward[offset+c] >= n {
+				return startX, startY, x, y
+			}
+		}
+		for c := -d; c <= d; c += 2 {
+			var x int
+			if c == -d || (c != d && backward[offset+c-1] < backward[offset+c+1]) {
+				x = backward[offset+c+1]
+			} else {
+				x = backward[offset+c-1] + 1
+			}
+			y := x - c
+			startX, startY := x, y
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+c] = x
+			if k := delta - c; !odd && k >= -d && k <= d && x+forward[offset+k] >= n {
+				return n - x, m - y, n - startX, m - startY
+			}
+		}
+	}
+	// Unreachable: the searches meet within limit steps
+	return 0, 0, 0, 0
+}
+
+// diffHunks groups diff lines into hunks, keeping diffContext unchanged
+// lines around each change
+func diffHunks(lines []DiffLine) []DiffHunk {
+	keep := make([]bool, len(lines))
+	for k, line := range lines {
+		if line.Kind == ' ' {
+			continue
+		}
+		for c := max(k-diffContext, 0); c <= min(k+diffContext, len(lines)-1); c++ {
+			keep[c] = true
+		}
+	}
+
+	var hunks []DiffHunk
+	var current *DiffHunk
+	oldLine, newLine := 1, 1
+	for k, line := range lines {
+		if !keep[k] {
+			if current != nil {
+				hunks = append(hunks, *current)
+				current = nil
+			}
+		} else {
+			if current == nil {
+				current = &DiffHunk{OldStart: oldLine, NewStart: newLine}
+			}
+			current.Lines = append(current.Lines, line)
+		}
+
+		switch line.Kind {
+		case ' ':
+			oldLine++
+			newLine++
+		case '-':
+			oldLine++
+		case '+':
+			newLine++
+		}
+	}
+	if current != nil {
+		hunks = append(hunks, *current)
+	}
+	return hunks
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Header formats the hunk position like a unified diff
+func (h DiffHunk) Header() string {
+	return fmt.Sprintf("@@ -%d +%d @@", h.OldStart, h.NewStart)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEditScript(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"both empty", "", "", ""},
+		{"identical", "abc", "abc", "   "},
+		{"insert everything", "", "ab", "++"},
+		{"delete everything", "ab", "", "--"},
+		{"insert in the middle", "ac", "abc", " + "},
+		{"delete at the end", "abc", "ab", "  -"},
+		{"replace one", "abc", "axc", " -+ "},
+		{"replace all", "ab", "xy", "--++"},
+		{"deletions come before insertions", "abcd", "xbyd", "-+ -+ "},
+		{"moved line", "abc", "bca", "-  +"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := strings.Split(tt.a, ""), strings.Split(tt.b, "")
+			got := string(editScript(a, b))
+			if got != tt.want {
+				t.Errorf("editScript(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+			}
+			checkEditScript(t, a, b, []byte(got))
+		})
+	}
+}
+
+// TestEditScriptRandom checks editScript against a quadratic LCS on random
+// inputs drawn from a small alphabet, so matches are frequent
+func TestEditScriptRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func() []string {
+		lines := make([]string, rng.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.Intn(4)))
+		}
+		return lines
+	}
+	for n := 0; n < 2000; n++ {
+		a, b := random(), random()
+		script := editScript(a, b)
+		checkEditScript(t, a, b, script)
+
+		edits := strings.Count(string(script), "-") + strings.Count(string(script), "+")
+		if want := len(a) + len(b) - 2*lcsLength(a, b); edits != want {
+			t.Fatalf("editScript(%q, %q) makes %d edits, want %d", a, b, edits, want)
+		}
+		if t.Failed() {
+			t.FailNow()
+		}
+	}
+}
+
+// checkEditScript verifies that script turns a into b and that each run of
+// changes lists its deletions first
+func checkEditScript(t *testing.T, a, b []string, script []byte) {
+	t.Helper()
+	got := []string{}
+	i := 0
+	for k, op := range script {
+		switch {
+		case op == ' ' && i < len(a):
+			got = append(got, a[i])
+			i++
+		case op == '-' && i < len(a):
+			if k > 0 && script[k-1] == '+' {
+				t.Errorf("script %q inserts before deleting", script)
+			}
+			i++
+		case op == '+' && len(got) < len(b):
+			got = append(got, b[len(got)])
+		default:
+			t.Fatalf("script %q turning %q into %q has an invalid op at %d", script, a, b, k)
+		}
+	}
+	if i != len(a) || !reflect.DeepEqual(got, append([]string{}, b...)) {
+		t.Errorf("script %q turns %q into %q, want %q", script, a, got, b)
+	}
+}
+
+func lcsLength(a, b []string) int {
+	row := make([]int, len(b)+1)
+	for i := range a {
+		prev := 0
+		for j := range b {
+			current := row[j+1]
+			if a[i] == b[j] {
+				row[j+1] = prev + 1
+			} else if row[j] > row[j+1] {
+				row[j+1] = row[j]
+			}
+			prev = current
+		}
+	}
+	return row[len(b)]
+}
+
+func TestLineDiff(t *testing.T) {
+	numbered := func(from, to int) []string {
+		var lines []string
+		for n := from; n <= to; n++ {
+			lines = append(lines, "line "+string(rune('a'+n-1)))
+		}
+		return lines
+	}
+	text := func(lines ...string) string { return strings.Join(lines, "\n") + "\n" }
+	file := numbered(1, 20)
+	changed := append(append(append([]string{}, file[:4]...), "new e"), file[5:]...)
+	twoChanges := append(append([]string{}, changed[:15]...), changed[16:]...)
+
+	tests := []struct {
+		name   string
+		old    string
+		new    string
+		ok     bool
+		starts [][2]int
+		lines  []string
+	}{
+		{"identical", text(file...), text(file...), true, nil, nil},
+		{"new file", "", "one\ntwo\n", true, [][2]int{{1, 1}}, []string{"+one", "+two"}},
+		{
+			"change with context",
+			text(file...), text(changed...), true,
+			[][2]int{{2, 2}},
+			[]string{" line b", " line c", " line d", "-line e", "+new e", " line f", " line g", " line h"},
+		},
+		{
+			"distant changes make separate hunks",
+			text(file...), text(twoChanges...), true,
+			[][2]int{{2, 2}, {13, 13}},
+			[]string{
+				" line b", " line c", " line d", "-line e", "+new e", " line f", " line g", " line h",
+				" line m", " line n", " line o", "-line p", " line q", " line r", " line s",
+			},
+		},
+		{"too long to diff", strings.Repeat("x\n", maxDiffLines+1), "x\n", false, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hunks, ok := lineDiff(tt.old, tt.new)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			var starts [][2]int
+			var lines []string
+			for _, hunk := range hunks {
+				starts = append(starts, [2]int{hunk.OldStart, hunk.NewStart})
+				for _, line := range hunk.Lines {
+					lines = append(lines, string(line.Kind)+line.Text)
+				}
+			}
+			if !reflect.DeepEqual(starts, tt.starts) {
+				t.Errorf("hunk starts = %v, want %v", starts, tt.starts)
+			}
+			if !reflect.DeepEqual(lines, tt.lines) {
+				t.Errorf("lines = %q, want %q", lines, tt.lines)
+			}
+		})
+	}
+}
@@ -4,31 +4,73 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"reflect"
+	"time"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		log.Fatal("Usage: go run main.go [--server] [--port 8080] <jsonl-file> [output.html]")
+	serverMode := flag.Bool("server", false, "start the web server")
+	port := flag.String("port", "8080", "port for the web server")
+	configPath := flag.String("config", defaultConfigPath(), "path to the JSON config file")
+	workers := flag.Int("workers", 0, "number of session files to scan concurrently (default: number of CPUs)")
+	var rootFlags rootFlag
+	flag.Var(&rootFlags, "root", "Claude data directory as [label=]path (repeatable)")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: go run . [--root [label=]path]... [--config file] [--workers n] [--server] [--port 8080] <jsonl-file> [output.html]")
+		fmt.Fprintln(os.Stderr, "       go run . [flags] query <text and filters>")
+		fmt.Fprintln(os.Stderr, "       go run . [flags] bash [--top N] [--n N] [filter]")
+		fmt.Fprintln(os.Stderr, "       go run . [flags] blocks [--n N] [--token-limit N]")
+		fmt.Fprintln(os.Stderr, "       go run . [flags] stats [--since 7d] [--top N]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	roots = resolveRoots(rootFlags, config)
+	applyPricingOverrides(config.Pricing)
+	planLimit = config.PlanLimit
+	if config.IdleMinutes > 0 {
+		idleThreshold = time.Duration(config.IdleMinutes) * time.Minute
+	}
+	if config.SessionCacheMB > 0 {
+		sessionCacheLimit = int64(config.SessionCacheMB) << 20
+	}
+	if *workers > 0 {
+		scanWorkers = *workers
+	} else if config.Workers > 0 {
+		scanWorkers = config.Workers
 	}
 
 	// Check for server mode
-	if os.Args[1] == "--server" {
-		port := "8080"
-		if len(os.Args) > 2 && os.Args[2] == "--port" && len(os.Args) > 3 {
-			port = os.Args[3]
+	if *serverMode {
+		startServer(*port)
+		return
+	}
+
+	args := flag.Args()
+	if len(args) < 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	
+	if command, ok := commands[args[0]]; ok {
+		if err := command(args[1:]); err != nil {
+			log.Fatal(err)
 		}
-		startServer(port)
 		return
 	}
 
-	filename := os.Args[1]
+	filename := args[0]
 	outputFile := "output.html"
-	if len(os.Args) > 2 {
-		outputFile = os.Args[2]
+	if len(args) > 1 {
+		outputFile = args[1]
 	}
 	
 	entries, err := parseJSONL(filename)
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const metricsPrefix = "claude_code_parser_"
+
+// durationBuckets are the upper bounds, in seconds, of the duration histograms
+var durationBuckets = [...]float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+// durationHistogram is a Prometheus histogram of durations that can be
+// updated from many goroutines
+type durationHistogram struct {
+	buckets [len(durationBuckets)]atomic.Int64
+	count   atomic.Int64
+	sum     atomic.Int64
+}
+
+// since records the time elapsed since start
+func (h *durationHistogram) since(start time.Time) {
+	d := time.Since(start)
+	seconds := d.Seconds()
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			h.buckets[i].Add(1)
+		}
+	}
+	h.count.Add(1)
+	h.sum.Add(int64(d))
+}
+
+// serverMetrics counts the work done by the session caches since startup
+var serverMetrics struct {
+	sessionCacheHits   atomic.Int64
+	sessionCacheMisses atomic.Int64
+	scanCacheHits      atomic.Int64
+	scanCacheMisses    atomic.Int64
+	parseDuration      durationHistogram
+	scanDuration       durationHistogram
+}
+
+// metricSample is one labelled value of a metric
+type metricSample struct {
+	labels string
+	value  float64
+}
+
+// metricFamily is a metric with its help text and samples
+type metricFamily struct {
+	name    string
+	kind    string
+	help    string
+	samples map[string]float64
+}
+
+func newMetricFamily(name, kind, help string) *metricFamily {
+	return &metricFamily{name: metricsPrefix + name, kind: kind, help: help, samples: make(map[string]float64)}
+}
+
+// add adds value to the sample with the given label pairs
+func (m *metricFamily) add(value float64, labels ...string) {
+	m.samples[formatLabels(labels...)] += value
+}
+
+func (m *metricFamily) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+	keys := make([]string, 0, len(m.samples))
+	for labels := range m.samples {
+		keys = append(keys, labels)
+	}
+	sort.Strings(keys)
+	for _, labels := range keys {
+		fmt.Fprintf(w, "%s%s %s\n", m.name, labels, formatMetricValue(m.samples[labels]))
+	}
+}
+
+// formatLabels renders name/value pairs as a Prometheus label set
+func formatLabels(pairs ...string) string {
+	if len(pairs) == 0 {
+		return ""
+	}
+	var parts []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, pairs[i]+`="`+labelEscaper.Replace(pairs[i+1])+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatMetricValue(value float64) string {
+	return fmt.Sprintf("%g", value)
+}
+
+func writeHistogram(w io.Writer, name, help string, h *durationHistogram) {
+	name = metricsPrefix + name
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for i, bound := range durationBuckets {
+		fmt.Fprintf(w, "%s_bucket{le=\"%g\"} %d\n", name, bound, h.buckets[i].Load())
+	}
+	count := h.count.Load()
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, count)
+	fmt.Fprintf(w, "%s_sum %g\n", name, time.Duration(h.sum.Load()).Seconds())
+	fmt.Fprintf(w, "%s_count %d\n", name, count)
+}
+
+func writeCounter(w io.Writer, name, help string, samples ...metricSample) {
+	name = metricsPrefix + name
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	for _, sample := range samples {
+		fmt.Fprintf(w, "%s%s %s\n", name, sample.labels, formatMetricValue(sample.value))
+	}
+}
+
+// metricsHandler serves usage and server metrics in the Prometheus text
+// format. Usage comes from the cached session scans, so a scrape only reads
+// session files that changed since the previous one.
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	sessions := listAllSessions()
+	scans := make([]sessionScan, len(sessions))
+	err := forEachParallel(r.Context(), scanWorkers, len(sessions), func(i int) {
+		scans[i] = scanSessionFile(sessions[i].Path)
+	})
+	if err != nil {
+		return
+	}
+	for i := range sessions {
+		sessions[i].Session.records = scans[i].records
+		sessions[i].Session.tools = scans[i].tools
+	}
+	records := uniqueRecords(loadedSessionInfos(sessions))
+	tools := uniqueToolRecords(loadedSessionInfos(sessions))
+
+	// Usage is totalled over the session files present at scrape time, so it
+	// drops when sessions are deleted or moved and is exported as gauges
+	sessionCount := newMetricFamily("sessions", "gauge", "Number of session files.")
+	lastActivity := newMetricFamily("last_activity_timestamp_seconds", "gauge", "Time of the latest entry in any session of the project.")
+	responses := newMetricFamily("responses", "gauge", "API responses, deduplicated by message ID.")
+	tokens := newMetricFamily("tokens", "gauge", "Tokens used by API responses.")
+	cost := newMetricFamily("cost_dollars", "gauge", "Estimated cost of API responses in US dollars.")
+	toolCalls := newMetricFamily("tool_calls", "gauge", "Tool calls made by the agent, deduplicated by tool use ID.")
+	toolErrors := newMetricFamily("tool_errors", "gauge", "Tool calls whose result was an error.")
+
+	for i, session := range sessions {
+		project := []string{"root", session.Project.Root.ID, "project", session.Project.DisplayName, "dir", session.Project.Name}
+		label := func(extra ...string) []string {
+			return append(append([]string(nil), project...), extra...)
+		}
+
+		sessionCount.add(1, project...)
+		if n := len(scans[i].timestamps); n > 0 {
+			latest := float64(scans[i].timestamps[n-1].Unix())
+			key := formatLabels(project...)
+			if latest > lastActivity.samples[key] {
+				lastActivity.samples[key] = latest
+			}
+		}
+		for _, record := range records[i] {
+			model := record.Model
+			responses.add(1, label("model", model)...)
+			tokens.add(float64(record.Usage.InputTokens), label("model", model, "type", "input")...)
+			tokens.add(float64(record.Usage.OutputTokens), label("model", model, "type", "output")...)
+			tokens.add(float64(record.Usage.CacheCreationInputTokens), label("model", model, "type", "cache_creation")...)
+			tokens.add(float64(record.Usage.CacheReadInputTokens), label("model", model, "type", "cache_read")...)
+			if c, ok := usageCost(model, record.Usage); ok {
+				cost.add(c, label("model", model)...)
+			}
+		}
+		for _, tool := range tools[i] {
+			toolCalls.add(1, label("tool", tool.Name)...)
+			if tool.Error {
+				toolErrors.add(1, label("tool", tool.Name)...)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	for _, family := range []*metricFamily{sessionCount, lastActivity, responses, tokens, cost, toolCalls, toolErrors} {
+		family.write(w)
+	}
+
+	writeCounter(w, "cache_requests_total", "Lookups in the parsed session and session scan caches.",
+		metricSample{formatLabels("cache", "entries", "result", "hit"), float64(serverMetrics.sessionCacheHits.Load())},
+		metricSample{formatLabels("cache", "entries", "result", "miss"), float64(serverMetrics.sessionCacheMisses.Load())},
+		metricSample{formatLabels("cache", "scan", "result", "hit"), float64(serverMetrics.scanCacheHits.Load())},
+		metricSample{formatLabels("cache", "scan", "result", "miss"), float64(serverMetrics.scanCacheMisses.Load())},
+	)
+	writeHistogram(w, "session_parse_duration_seconds", "Time spent fully parsing a session file.", &serverMetrics.parseDuration)
+	writeHistogram(w, "session_scan_duration_seconds", "Time spent scanning a session file for usage and tool calls.", &serverMetrics.scanDuration)
+	fmt.Fprintf(w, "# HELP %[1]sscrape_duration_seconds Time spent computing these metrics.\n# TYPE %[1]sscrape_duration_seconds gauge\n%[1]sscrape_duration_seconds %[2]g\n",
+		metricsPrefix, time.Since(start).Seconds())
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+const maxResponseExamples = 50
+
+// ResponseCount counts the API responses with one model, stop reason or
+// service tier
+type ResponseCount struct {
+	Name         string
+	Responses    int
+	Sessions     int
+	OutputTokens int
+	Cost         float64
+}
+
+// ModelSwitch is a main-conversation response that used a different model
+// than the one before it
+type ModelSwitch struct {
+	Project   ProjectInfo
+	Session   SessionInfo
+	EntryUUID string
+	Timestamp time.Time
+	From      string
+	To        string
+}
+
+// TruncatedResponse is a response cut off by the output token limit
+type TruncatedResponse struct {
+	Project      ProjectInfo
+	Session      SessionInfo
+	EntryUUID    string
+	Timestamp    time.Time
+	Model        string
+	OutputTokens int
+}
+
+// ResponseBreakdown describes which models served a set of responses, why
+// they stopped and which service tier handled them
+type ResponseBreakdown struct {
+	Sessions      int
+	Responses     int
+	Models        []ResponseCount
+	StopReasons   []ResponseCount
+	ServiceTiers  []ResponseCount
+	Switches      []ModelSwitch
+	Truncated     []TruncatedResponse
+	TruncatedRate float64
+	// SwitchSessions is the number of sessions that changed model
+	SwitchSessions int
+}
+
+// sessionRecords is the usage of one session with its location
+type sessionRecords struct {
+	Project ProjectInfo
+	Session SessionInfo
+	Records []usageRecord
+}
+
+type responseCounter struct {
+	counts   map[string]*ResponseCount
+	sessions map[string]map[int]bool
+}
+
+func newResponseCounter() *responseCounter {
+	return &responseCounter{counts: make(map[string]*ResponseCount), sessions: make(map[string]map[int]bool)}
+}
+
+func (c *responseCounter) add(name string, session int, record usageRecord) {
+	count := c.counts[name]
+	if count == nil {
+		count = &ResponseCount{Name: name}
+		c.counts[name] = count
+		c.sessions[name] = make(map[int]bool)
+	}
+	count.Responses++
+	count.OutputTokens += record.Usage.OutputTokens
+	cost, _ := usageCost(record.Model, record.Usage)
+	count.Cost += cost
+	if !c.sessions[name][session] {
+		c.sessions[name][session] = true
+		count.Sessions++
+	}
+}
+
+// sorted returns the counts with the most responses first
+func (c *responseCounter) sorted() []ResponseCount {
+	var counts []ResponseCount
+	for _, count := range c.counts {
+		counts = append(counts, *count)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Responses != counts[j].Responses {
+			return counts[i].Responses > counts[j].Responses
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
+
+// buildResponseBreakdown counts models, stop reasons and service tiers and
+// finds model switches and truncated responses
+func buildResponseBreakdown(sessions []sessionRecords) ResponseBreakdown {
+	breakdown := ResponseBreakdown{Sessions: len(sessions)}
+	models := newResponseCounter()
+	stopReasons := newResponseCounter()
+	tiers := newResponseCounter()
+
+	for i, session := range sessions {
+		previous := ""
+		switched := false
+		for _, record := range session.Records {
+			breakdown.Responses++
+			models.add(record.Model, i, record)
+			stopReasons.add(orUnknown(record.StopReason), i, record)
+			tiers.add(orUnknown(record.Usage.ServiceTier), i, record)
+
+			if record.StopReason == "max_tokens" {
+				breakdown.Truncated = append(breakdown.Truncated, TruncatedResponse{
+					Project:      session.Project,
+					Session:      session.Session,
+					EntryUUID:    record.EntryUUID,
+					Timestamp:    record.Timestamp,
+					Model:        record.Model,
+					OutputTokens: record.Usage.OutputTokens,
+				})
+			}
+
+			// Subagents often run on another model, and synthetic responses
+			// are written by Claude Code itself, so neither is a switch
+			if record.Sidechain || record.Model == "<synthetic>" {
+				continue
+			}
+			if previous != "" && record.Model != previous {
+				breakdown.Switches = append(breakdown.Switches, ModelSwitch{
+					Project:   session.Project,
+					Session:   session.Session,
+					EntryUUID: record.EntryUUID,
+					Timestamp: record.Timestamp,
+					From:      previous,
+					To:        record.Model,
+				})
+				switched = true
+			}
+			previous = record.Model
+		}
+		if switched {
+			breakdown.SwitchSessions++
+		}
+	}
+
+	breakdown.Models = models.sorted()
+	breakdown.StopReasons = stopReasons.sorted()
+	breakdown.ServiceTiers = tiers.sorted()
+	if breakdown.Responses > 0 {
+		breakdown.TruncatedRate = float64(len(breakdown.Truncated)) / float64(breakdown.Responses)
+	}
+
+	sort.SliceStable(breakdown.Switches, func(i, j int) bool {
+		return breakdown.Switches[i].Timestamp.After(breakdown.Switches[j].Timestamp)
+	})
+	if len(breakdown.Switches) > maxResponseExamples {
+		breakdown.Switches = breakdown.Switches[:maxResponseExamples]
+	}
+	sort.SliceStable(breakdown.Truncated, func(i, j int) bool {
+		return breakdown.Truncated[i].Timestamp.After(breakdown.Truncated[j].Timestamp)
+	})
+	if len(breakdown.Truncated) > maxResponseExamples {
+		breakdown.Truncated = breakdown.Truncated[:maxResponseExamples]
+	}
+
+	return breakdown
+}
+
+// sessionResponseBreakdown describes the responses of a single parsed session
+func sessionResponseBreakdown(entries []LogEntry) ResponseBreakdown {
+	return buildResponseBreakdown([]sessionRecords{{Records: usageRecords(entries)}})
+}
+
+// getResponseBreakdown describes the responses of every session
+func getResponseBreakdown(ctx context.Context) (ResponseBreakdown, error) {
+	listed := listAllSessions()
+	sessions := make([]sessionRecords, len(listed))
+	err := forEachParallel(ctx, scanWorkers, len(listed), func(i int) {
+		sessions[i] = sessionRecords{
+			Project: listed[i].Project,
+			Session: listed[i].Session,
+			Records: readSessionUsageRecords(listed[i].Path),
+		}
+	})
+	if err != nil {
+		return ResponseBreakdown{}, err
+	}
+	for i := range listed {
+		listed[i].Session.records = sessions[i].Records
+	}
+	for i, records := range uniqueRecords(loadedSessionInfos(listed)) {
+		sessions[i].Records = records
+	}
+	return buildResponseBreakdown(sessions), nil
+}
+
+// responseShare returns a count's share of all responses
+func (b ResponseBreakdown) responseShare(count ResponseCount) float64 {
+	if b.Responses == 0 {
+		return 0
+	}
+	return float64(count.Responses) / float64(b.Responses)
+}
+
+func orUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
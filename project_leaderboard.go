@@ -0,0 +1,191 @@
+package main
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProjectStats is one row of the project leaderboard
+type ProjectStats struct {
+	Project      ProjectInfo
+	Sessions     int
+	Usage        UsageTotals
+	Active       time.Duration
+	ToolCalls    int
+	ToolErrors   int
+	LastActivity time.Time
+}
+
+// ErrorRate is the share of tool calls whose result was an error
+func (s ProjectStats) ErrorRate() float64 {
+	if s.ToolCalls == 0 {
+		return 0
+	}
+	return float64(s.ToolErrors) / float64(s.ToolCalls)
+}
+
+// ProjectLeaderboard ranks projects across all data roots
+type ProjectLeaderboard struct {
+	Rows   []ProjectStats
+	Total  ProjectStats
+	Filter string
+	Sort   string
+	Desc   bool
+	// Hidden counts the projects removed by the filter
+	Hidden int
+}
+
+// leaderboardSorts are the columns the leaderboard can be sorted by
+var leaderboardSorts = []string{"name", "sessions", "tokens", "cost", "active", "errors", "activity"}
+
+// projectStats totals the scanned metadata of a project's sessions
+func projectStats(project ProjectInfo) ProjectStats {
+	stats := ProjectStats{
+		Project:      project,
+		Sessions:     len(project.Sessions),
+		Usage:        projectUsage(project),
+		LastActivity: project.ModTime,
+	}
+	stats.ToolCalls, stats.ToolErrors = sessionsToolCounts(project.Sessions)
+	for _, session := range project.Sessions {
+		stats.Active += session.Activity.Active
+		if session.Activity.End.After(stats.LastActivity) {
+			stats.LastActivity = session.Activity.End
+		}
+	}
+	return stats
+}
+
+// buildProjectLeaderboard filters projects by name or path and sorts them.
+// Unknown sort columns fall back to cost, most expensive first.
+func buildProjectLeaderboard(projects []ProjectInfo, filter, sortBy, order string) ProjectLeaderboard {
+	board := ProjectLeaderboard{Filter: strings.TrimSpace(filter), Sort: "cost"}
+	for _, s := range leaderboardSorts {
+		if s == sortBy {
+			board.Sort = s
+		}
+	}
+	board.Desc = board.Sort != "name"
+	switch order {
+	case "asc":
+		board.Desc = false
+	case "desc":
+		board.Desc = true
+	}
+
+	needle := strings.ToLower(board.Filter)
+	var listed []ProjectInfo
+	var sessions []SessionInfo
+	for _, project := range projects {
+		if needle != "" && !projectMatches(project, needle) {
+			board.Hidden++
+			continue
+		}
+		stats := projectStats(project)
+		board.Rows = append(board.Rows, stats)
+		listed = append(listed, project)
+		sessions = append(sessions, project.Sessions...)
+		board.Total.Active += stats.Active
+		board.Total.Sessions += stats.Sessions
+		if stats.LastActivity.After(board.Total.LastActivity) {
+			board.Total.LastActivity = stats.LastActivity
+		}
+	}
+	// The same session can be listed under several roots, so the total is
+	// not the sum of the rows
+	board.Total.Usage = projectsUsage(listed)
+	board.Total.ToolCalls, board.Total.ToolErrors = sessionsToolCounts(sessions)
+
+	sort.SliceStable(board.Rows, func(i, j int) bool {
+		a, b := board.Rows[i], board.Rows[j]
+		if board.Desc {
+			a, b = b, a
+		}
+		return leaderboardLess(a, b, board.Sort)
+	})
+	return board
+}
+
+// sessionsToolCounts counts the tool calls and failures of a set of sessions,
+// counting calls shared by resumed sessions once
+func sessionsToolCounts(sessions []SessionInfo) (int, int) {
+	calls, errors := 0, 0
+	for _, tools := range uniqueToolRecords(sessions) {
+		c, e := countToolRecords(tools)
+		calls += c
+		errors += e
+	}
+	return calls, errors
+}
+
+func projectMatches(project ProjectInfo, needle string) bool {
+	for _, field := range []string{project.DisplayName, project.Name, project.Cwd, project.Root.Label} {
+		if strings.Contains(strings.ToLower(field), needle) {
+			return true
+		}
+	}
+	return false
+}
+
+func leaderboardLess(a, b ProjectStats, sortBy string) bool {
+	switch sortBy {
+	case "name":
+		return strings.ToLower(a.Project.DisplayName) < strings.ToLower(b.Project.DisplayName)
+	case "sessions":
+		return a.Sessions < b.Sessions
+	case "tokens":
+		return a.Usage.TotalTokens() < b.Usage.TotalTokens()
+	case "active":
+		return a.Active < b.Active
+	case "errors":
+		return a.ErrorRate() < b.ErrorRate()
+	case "activity":
+		return a.LastActivity.Before(b.LastActivity)
+	}
+	return a.Usage.Cost < b.Usage.Cost
+}
+
+// CostShare is a project's share of the cost of all listed projects
+func (l ProjectLeaderboard) CostShare(stats ProjectStats) float64 {
+	if l.Total.Usage.Cost == 0 {
+		return 0
+	}
+	return stats.Usage.Cost / l.Total.Usage.Cost
+}
+
+// SortURL links to the leaderboard sorted by a column. Selecting the current
+// column again reverses the order.
+func (l ProjectLeaderboard) SortURL(column string) string {
+	desc := column != "name"
+	if column == l.Sort {
+		desc = !l.Desc
+	}
+	return leaderboardURL(l.Filter, column, desc)
+}
+
+// SortIndicator marks the column the leaderboard is sorted by
+func (l ProjectLeaderboard) SortIndicator(column string) string {
+	if column != l.Sort {
+		return ""
+	}
+	if l.Desc {
+		return " ▼"
+	}
+	return " ▲"
+}
+
+// leaderboardURL links to the project leaderboard on the index page
+func leaderboardURL(filter, sortBy string, desc bool) string {
+	query := url.Values{"view": {"table"}, "sort": {sortBy}}
+	if desc {
+		query.Set("order", "desc")
+	} else {
+		query.Set("order", "asc")
+	}
+	if filter != "" {
+		query.Set("q", filter)
+	}
+	return "/?" + query.Encode()
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ProjectGroup is a set of projects that belong to the same git repository,
+// such as the main checkout and its worktrees
+type ProjectGroup struct {
+	Name     string
+	RepoPath string
+	Projects []ProjectInfo
+}
+
+// ProjectSession pairs a session with the project it belongs to
+type ProjectSession struct {
+	Project ProjectInfo
+	Session SessionInfo
+}
+
+// readSessionCwd returns the first working directory recorded in a session file
+func readSessionCwd(sessionPath string) string {
+	file, err := os.Open(sessionPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 10*1024*1024)
+
+	for scanner.Scan() {
+		var entry struct {
+			Cwd string `json:"cwd"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if entry.Cwd != "" {
+			return entry.Cwd
+		}
+	}
+	return ""
+}
+
+// decodeProjectDirName recovers a working directory from a project directory
+// name. Claude Code replaces path separators with dashes, which is ambiguous
+// for directories whose names contain dashes, so existing directories on disk
+// are preferred when resolving each component.
+func decodeProjectDirName(name string) string {
+	tokens := strings.Split(strings.TrimPrefix(name, "-"), "-")
+	if resolved, ok := resolveDirTokens(string(filepath.Separator), tokens); ok {
+		return resolved
+	}
+	return "/" + strings.Join(tokens, "/")
+}
+
+func resolveDirTokens(dir string, tokens []string) (string, bool) {
+	if len(tokens) == 0 {
+		return dir, true
+	}
+	// Try the longest dash-joined component first
+	for k := len(tokens); k >= 1; k-- {
+		candidate := filepath.Join(dir, strings.Join(tokens[:k], "-"))
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			if resolved, ok := resolveDirTokens(candidate, tokens[k:]); ok {
+				return resolved, true
+			}
+		}
+	}
+	return "", false
+}
+
+// findGitRepo returns the top-level directory of the git repository that
+// contains dir. Worktrees resolve to their main repository.
+func findGitRepo(dir string) string {
+	for current := filepath.Clean(dir); ; current = filepath.Dir(current) {
+		gitPath := filepath.Join(current, ".git")
+		if info, err := os.Stat(gitPath); err == nil {
+			if info.IsDir() {
+				return current
+			}
+			if repo := worktreeMainRepo(gitPath); repo != "" {
+				return repo
+			}
+			return current
+		}
+
+		if parent := filepath.Dir(current); parent == current {
+			return ""
+		}
+	}
+}
+
+// worktreeMainRepo follows the gitdir pointer in a worktree's .git file back
+// to the repository that owns it
+func worktreeMainRepo(gitFile string) string {
+	data, err := os.ReadFile(gitFile)
+	if err != nil {
+		return ""
+	}
+
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return ""
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(gitFile), gitDir)
+	}
+
+	commonDir := gitDir
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir = strings.TrimSpace(string(data))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+	} else if idx := strings.LastIndex(gitDir, string(filepath.Separator)+"worktrees"+string(filepath.Separator)); idx != -1 {
+		commonDir = gitDir[:idx]
+	}
+
+	commonDir = filepath.Clean(commonDir)
+	if filepath.Base(commonDir) == ".git" {
+		return filepath.Dir(commonDir)
+	}
+	return commonDir
+}
+
+// resolveProjectLocation fills in the working directory, display name and
+// repository of a project
+func resolveProjectLocation(project *ProjectInfo) {
+	for _, session := range project.Sessions {
+		if cwd := readSessionCwd(filepath.Join(project.Path, session.Filename)); cwd != "" {
+			project.Cwd = cwd
+			break
+		}
+	}
+	if project.Cwd == "" {
+		project.Cwd = decodeProjectDirName(project.Name)
+	}
+
+	project.DisplayName = projectDisplayName(project.Cwd, project.Name)
+	project.RepoPath = findGitRepo(project.Cwd)
+}
+
+// projectDisplayName returns a readable name for a project, preferring the
+// recorded working directory over the encoded directory name
+func projectDisplayName(cwd, projectName string) string {
+	if cwd == "" {
+		cwd = decodeProjectDirName(projectName)
+	}
+	return filepath.Base(cwd)
+}
+
+// groupProjectsByRepo merges projects that share a git repository. Projects
+// whose directory is no longer on disk are grouped by their own path.
+func groupProjectsByRepo(projects []ProjectInfo) []ProjectGroup {
+	var groups []ProjectGroup
+	index := make(map[string]int)
+
+	for _, project := range projects {
+		key := project.RepoPath
+		if key == "" {
+			key = project.Cwd
+		}
+
+		if i, ok := index[key]; ok {
+			groups[i].Projects = append(groups[i].Projects, project)
+			continue
+		}
+
+		index[key] = len(groups)
+		groups = append(groups, ProjectGroup{
+			Name:     filepath.Base(key),
+			RepoPath: key,
+			Projects: []ProjectInfo{project},
+		})
+	}
+
+	return groups
+}
+
+// recentGroupSessions returns the most recent sessions across all projects in a group
+func recentGroupSessions(group ProjectGroup, limit int) []ProjectSession {
+	var sessions []ProjectSession
+	for _, project := range group.Projects {
+		for _, session := range project.Sessions {
+			sessions = append(sessions, ProjectSession{Project: project, Session: session})
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Session.ModTime.After(sessions[j].Session.ModTime)
+	})
+
+	if len(sessions) > limit {
+		sessions = sessions[:limit]
+	}
+	return sessions
+}
+
+func countGroupSessions(group ProjectGroup) int {
+	count := 0
+	for _, project := range group.Projects {
+		count += len(project.Sessions)
+	}
+	return count
+}
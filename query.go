@@ -0,0 +1,283 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query is a parsed search query. Free text is matched against the search
+// index while the other fields filter sessions and entries. Repeating a
+// filter key matches any of its values; different keys must all match.
+type Query struct {
+	Text       string
+	Tools      []string
+	Files      []string
+	Projects   []string
+	Branches   []string
+	Models     []string
+	Roles      []string
+	Since      time.Time
+	Until      time.Time
+	ErrorsOnly bool
+}
+
+// queryHelp describes the filter syntax for the search page and CLI usage
+const queryHelp = `tool:NAME file:PATH project:NAME branch:GLOB model:NAME since:DATE until:DATE is:error role:user|assistant`
+
+// ParseQuery parses a query such as `tool:Bash is:error project:api since:7d
+// timeout`. Dates are YYYY-MM-DD or relative durations like 24h, 7d and 2w.
+func ParseQuery(input string, now time.Time) (Query, error) {
+	var q Query
+	var text []string
+
+	for _, word := range splitQueryWords(input) {
+		key, value, ok := strings.Cut(word, ":")
+		if !ok || value == "" {
+			text = append(text, word)
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "tool":
+			q.Tools = append(q.Tools, value)
+		case "file":
+			q.Files = append(q.Files, value)
+		case "project":
+			q.Projects = append(q.Projects, value)
+		case "branch":
+			q.Branches = append(q.Branches, value)
+		case "model":
+			q.Models = append(q.Models, value)
+		case "role":
+			q.Roles = append(q.Roles, strings.ToLower(value))
+		case "since":
+			t, err := parseQueryTime(value, now, false)
+			if err != nil {
+				return q, err
+			}
+			q.Since = t
+		case "until":
+			t, err := parseQueryTime(value, now, true)
+			if err != nil {
+				return q, err
+			}
+			q.Until = t
+		case "is":
+			if strings.ToLower(value) != "error" {
+				return q, fmt.Errorf("unknown filter is:%s", value)
+			}
+			q.ErrorsOnly = true
+		default:
+			// Not a filter, e.g. a URL or a Go selector
+			text = append(text, word)
+		}
+	}
+
+	q.Text = strings.Join(text, " ")
+	return q, nil
+}
+
+// splitQueryWords splits on whitespace, keeping double-quoted sections together
+func splitQueryWords(input string) []string {
+	var words []string
+	var current strings.Builder
+	inQuotes := false
+
+	for _, r := range input {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case !inQuotes && (r == ' ' || r == '\t' || r == '\n'):
+			if current.Len() > 0 {
+				words = append(words, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		words = append(words, current.String())
+	}
+	return words
+}
+
+// parseQueryTime parses an absolute date or a duration relative to now. For
+// until: dates, the whole day is included.
+func parseQueryTime(value string, now time.Time, endOfDay bool) (time.Time, error) {
+	if value == "today" {
+		value = now.Format("2006-01-02")
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		if endOfDay {
+			return t.AddDate(0, 0, 1), nil
+		}
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	if len(value) > 1 {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err == nil {
+			switch value[len(value)-1] {
+			case 'm':
+				return now.Add(-time.Duration(n) * time.Minute), nil
+			case 'h':
+				return now.Add(-time.Duration(n) * time.Hour), nil
+			case 'd':
+				return now.AddDate(0, 0, -n), nil
+			case 'w':
+				return now.AddDate(0, 0, -7*n), nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD or a duration like 24h, 7d, 2w", value)
+}
+
+// HasFilters reports whether the query restricts results beyond free text
+func (q Query) HasFilters() bool {
+	return len(q.Tools) > 0 || len(q.Files) > 0 || len(q.Projects) > 0 ||
+		len(q.Branches) > 0 || len(q.Models) > 0 || len(q.Roles) > 0 ||
+		!q.Since.IsZero() || !q.Until.IsZero() || q.ErrorsOnly
+}
+
+// queryEntry is what the entry filters of a query look at in a log entry.
+// Tool, File and IsError describe the tool call a matched block belongs to;
+// Tool is empty for text blocks.
+type queryEntry struct {
+	Timestamp time.Time
+	Role      string
+	GitBranch string
+	Model     string
+	Tool      string
+	File      string
+	IsError   bool
+}
+
+// MatchSession applies the filters that describe a whole session: its
+// project, and the branches and models its entries recorded
+func (q Query) MatchSession(project ProjectInfo, branches, models []string) bool {
+	if len(q.Projects) > 0 {
+		if !matchAny(q.Projects, project.DisplayName, project.Name, project.Cwd) {
+			return false
+		}
+	}
+
+	if len(q.Branches) > 0 {
+		branchMatched := false
+		for _, branch := range branches {
+			if matchAnyGlob(q.Branches, branch) {
+				branchMatched = true
+				break
+			}
+		}
+		if !branchMatched {
+			return false
+		}
+	}
+	if len(q.Models) > 0 && !matchAny(q.Models, models...) {
+		return false
+	}
+
+	return true
+}
+
+// MatchEntry applies the filters that describe a single entry
+func (q Query) MatchEntry(entry queryEntry) bool {
+	if !q.Since.IsZero() && entry.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !entry.Timestamp.Before(q.Until) {
+		return false
+	}
+	if len(q.Roles) > 0 && !matchAny(q.Roles, entry.Role) {
+		return false
+	}
+	if len(q.Branches) > 0 && entry.GitBranch != "" && !matchAnyGlob(q.Branches, entry.GitBranch) {
+		return false
+	}
+	if len(q.Models) > 0 && entry.Model != "" && !matchAny(q.Models, entry.Model) {
+		return false
+	}
+
+	if len(q.Tools) > 0 || len(q.Files) > 0 || q.ErrorsOnly {
+		if entry.Tool == "" {
+			return false
+		}
+		if len(q.Tools) > 0 && !matchAnyTool(q.Tools, entry.Tool) {
+			return false
+		}
+		if len(q.Files) > 0 && (entry.File == "" || !matchAnyFile(q.Files, entry.File)) {
+			return false
+		}
+		if q.ErrorsOnly && !entry.IsError {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchAny reports whether any pattern is a case-insensitive substring of
+// any value, or matches it as a glob
+func matchAny(patterns []string, values ...string) bool {
+	for _, pattern := range patterns {
+		p := strings.ToLower(pattern)
+		for _, value := range values {
+			v := strings.ToLower(value)
+			if strings.Contains(v, p) {
+				return true
+			}
+			if ok, _ := path.Match(p, v); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchAnyGlob(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if pattern == value {
+			return true
+		}
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAnyTool(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if strings.EqualFold(pattern, name) {
+			return true
+		}
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAnyFile matches a file path by suffix, so file:main.go and
+// file:cmd/main.go both find /src/app/cmd/main.go, or by glob
+func matchAnyFile(patterns []string, filePath string) bool {
+	for _, pattern := range patterns {
+		if filePath == pattern || strings.HasSuffix(filePath, "/"+strings.TrimPrefix(pattern, "/")) {
+			return true
+		}
+		if ok, _ := path.Match(pattern, filePath); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(filePath)); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name    string
+		input   string
+		want    Query
+		wantErr bool
+	}{
+		{"free text", "connection refused", Query{Text: "connection refused"}, false},
+		{"tool filter", "tool:Bash timeout", Query{Text: "timeout", Tools: []string{"Bash"}}, false},
+		{"repeated filter", "tool:Edit tool:Write", Query{Tools: []string{"Edit", "Write"}}, false},
+		{"keys are case insensitive", "TOOL:Bash Is:error", Query{Tools: []string{"Bash"}, ErrorsOnly: true}, false},
+		{"every filter", "file:main.go project:api branch:feat/* model:opus role:User",
+			Query{Files: []string{"main.go"}, Projects: []string{"api"}, Branches: []string{"feat/*"}, Models: []string{"opus"}, Roles: []string{"user"}}, false},
+		{"quoted text keeps spaces", `"exit status 1" tool:Bash`, Query{Text: "exit status 1", Tools: []string{"Bash"}}, false},
+		{"quoted filter value", `file:"my file.go"`, Query{Files: []string{"my file.go"}}, false},
+		{"unknown key is text", "http://example.com fmt.Println", Query{Text: "http://example.com fmt.Println"}, false},
+		{"empty value is text", "tool: x", Query{Text: "tool: x"}, false},
+		{"since date", "since:2026-03-01", Query{Since: day(1)}, false},
+		{"until date includes the day", "until:2026-03-10", Query{Until: day(11)}, false},
+		{"since today", "since:today", Query{Since: day(15)}, false},
+		{"relative hours", "since:24h", Query{Since: now.Add(-24 * time.Hour)}, false},
+		{"relative days", "since:7d", Query{Since: day(8).Add(12 * time.Hour)}, false},
+		{"relative weeks", "since:2w", Query{Since: day(1).Add(12 * time.Hour)}, false},
+		{"RFC 3339 time", "until:2026-03-02T10:00:00Z", Query{Until: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)}, false},
+		{"invalid date", "since:yesterday", Query{}, true},
+		{"invalid duration", "since:7y", Query{}, true},
+		{"unknown is value", "is:open", Query{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseQuery(tt.input, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseQuery(%q) succeeded, want an error", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseQuery(%q): %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryHasFilters(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"just text", false},
+		{"tool:Bash", true},
+		{"is:error", true},
+		{"since:1d text", true},
+	}
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.input, now)
+		if err != nil {
+			t.Fatalf("ParseQuery(%q): %v", tt.input, err)
+		}
+		if got := q.HasFilters(); got != tt.want {
+			t.Errorf("ParseQuery(%q).HasFilters() = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
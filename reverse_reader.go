@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+const reverseReadChunkSize = 64 * 1024
+
+// reverseLineReader reads the lines of a file from the last one to the first
+// without loading the whole file
+type reverseLineReader struct {
+	file   *os.File
+	offset int64
+	buf    []byte
+	// pending holds the chunks after buf that belong to the line being read,
+	// last chunk of the file first, so a long line is copied only once
+	pending [][]byte
+}
+
+func newReverseLineReader(file *os.File) (*reverseLineReader, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &reverseLineReader{file: file, offset: info.Size()}, nil
+}
+
+// ReadLine returns the previous line without its trailing newline, or io.EOF
+// once the start of the file has been reached
+func (r *reverseLineReader) ReadLine() ([]byte, error) {
+	for {
+		if idx := bytes.LastIndexByte(r.buf, '\n'); idx >= 0 {
+			line := r.join(r.buf[idx+1:])
+			r.buf = r.buf[:idx]
+			return line, nil
+		}
+
+		if r.offset == 0 {
+			if r.buf == nil && r.pending == nil {
+				return nil, io.EOF
+			}
+			line := r.join(r.buf)
+			r.buf = nil
+			return line, nil
+		}
+
+		if len(r.buf) > 0 {
+			r.pending = append(r.pending, r.buf)
+		}
+
+		size := int64(reverseReadChunkSize)
+		if size > r.offset {
+			size = r.offset
+		}
+		r.offset -= size
+
+		chunk := make([]byte, size)
+		if _, err := r.file.ReadAt(chunk, r.offset); err != nil && err != io.EOF {
+			return nil, err
+		}
+		r.buf = chunk
+	}
+}
+
+// join completes the line starting with head from the pending chunks
+func (r *reverseLineReader) join(head []byte) []byte {
+	if r.pending == nil {
+		return head
+	}
+	n := len(head)
+	for _, chunk := range r.pending {
+		n += len(chunk)
+	}
+	line := make([]byte, 0, n)
+	line = append(line, head...)
+	for i := len(r.pending) - 1; i >= 0; i-- {
+		line = append(line, r.pending[i]...)
+	}
+	r.pending = nil
+	return line
+}
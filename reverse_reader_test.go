@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReverseLineReader(t *testing.T) {
+	long := strings.Repeat("x", 3*reverseReadChunkSize+17)
+	// Ending a file with lastChunk puts the newline before it at the first
+	// byte of the last chunk; with fullChunk, at the last byte of the chunk
+	// before
+	lastChunk := strings.Repeat("y", reverseReadChunkSize-1)
+	fullChunk := strings.Repeat("z", reverseReadChunkSize)
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"empty file", "", nil},
+		{"single line", "only", []string{"only"}},
+		{"trailing newline", "a\nb\n", []string{"", "b", "a"}},
+		{"blank lines", "a\n\n\nb", []string{"b", "", "", "a"}},
+		{"multi-byte text", "héllo\n世界", []string{"世界", "héllo"}},
+		{"line longer than a chunk", "first\n" + long + "\nlast", []string{"last", long, "first"}},
+		{"whole file is one long line", long, []string{long}},
+		{"newline starting a chunk", "head\n" + lastChunk, []string{lastChunk, "head"}},
+		{"newline ending a chunk", "head\n" + fullChunk, []string{fullChunk, "head"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "session.jsonl")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			reader, err := newReverseLineReader(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for {
+				line, err := reader.ReadLine()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("ReadLine: %v", err)
+				}
+				got = append(got, string(line))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %d lines %v, want %d lines %v", len(got), shorten(got), len(tt.want), shorten(tt.want))
+			}
+		})
+	}
+}
+
+// shorten keeps failure messages readable when lines are long
+func shorten(lines []string) []string {
+	short := make([]string, len(lines))
+	for i, line := range lines {
+		short[i] = truncateRunes(line, 20)
+	}
+	return short
+}
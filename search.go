@@ -0,0 +1,513 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxSearchResults = 100
+	snippetContext   = 80
+	snippetLength    = 240
+)
+
+// SearchResult is a single entry that matched a search query
+type SearchResult struct {
+	Project   ProjectInfo
+	Session   SessionInfo
+	EntryUUID string
+	Timestamp time.Time
+	Kind      string
+	Tool      string
+	Score     float64
+	Snippet   []SnippetPart
+}
+
+// SnippetPart is a piece of a result snippet, marked when it matched a query term
+type SnippetPart struct {
+	Text  string
+	Match bool
+}
+
+// searchField is a piece of searchable text extracted from a log entry
+type searchField struct {
+	Kind      string
+	Tool      string
+	ToolUseID string
+	Text      string
+}
+
+type searchDoc struct {
+	session   int
+	toolUseID string
+	entryUUID string
+	kind      string
+	tool      string
+	text      string
+	// entry holds what the entry filters of a query look at
+	entry queryEntry
+}
+
+type posting struct {
+	doc  int
+	freq int
+}
+
+// searchSession is an indexed session: what results show about it and what
+// the session filters of a query look at
+type searchSession struct {
+	Project  ProjectInfo
+	Session  SessionInfo
+	branches []string
+	models   []string
+}
+
+// SearchIndex is an inverted index over the text of every session. It keeps
+// the extracted text, not the parsed entries, so that its memory does not
+// grow with what the session cache evicts.
+type SearchIndex struct {
+	sessions  []searchSession
+	docs      []searchDoc
+	postings  map[string][]posting
+	signature uint64
+}
+
+type token struct {
+	term  string
+	start int
+	end   int
+}
+
+var searchIndex = struct {
+	sync.Mutex
+	index *SearchIndex
+}{}
+
+// getSearchIndex returns the search index, rebuilding it when the listing
+// shows that a session file was added, removed or changed since it was
+// last built
+func getSearchIndex(ctx context.Context) (*SearchIndex, error) {
+	sessions := listAllSessions()
+	signature := sessionsSignature(sessions)
+
+	searchIndex.Lock()
+	defer searchIndex.Unlock()
+
+	if searchIndex.index == nil || searchIndex.index.signature != signature {
+		index, err := buildSearchIndex(ctx, sessions)
+		if err != nil {
+			return nil, err
+		}
+		index.signature = signature
+		searchIndex.index = index
+	}
+	return searchIndex.index, nil
+}
+
+func sessionsSignature(sessions []LoadedSession) uint64 {
+	paths := make([]string, 0, len(sessions))
+	for _, s := range sessions {
+		paths = append(paths, fmt.Sprintf("%s:%d:%d", s.Path, s.Session.ModTime.UnixNano(), s.Session.Size))
+	}
+	sort.Strings(paths)
+
+	hash := fnv.New64a()
+	for _, path := range paths {
+		hash.Write([]byte(path))
+		hash.Write([]byte{0})
+	}
+	return hash.Sum64()
+}
+
+// buildSearchIndex parses listed sessions on the scan worker pool and
+// indexes their text. Sessions that fail to parse are skipped.
+func buildSearchIndex(ctx context.Context, listed []LoadedSession) (*SearchIndex, error) {
+	sessions := make([]searchSession, len(listed))
+	docs := make([][]searchDoc, len(listed))
+	err := forEachParallel(ctx, scanWorkers, len(listed), func(i int) {
+		sessions[i] = searchSession{Project: listed[i].Project, Session: listed[i].Session}
+		entries, err := loadSessionEntries(listed[i].Path)
+		if err != nil {
+			return
+		}
+		sessions[i].Session.Title = getSessionTitle(entries)
+		sessions[i].branches, sessions[i].models, docs[i] = sessionSearchDocs(entries)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	index := &SearchIndex{
+		sessions: sessions,
+		postings: make(map[string][]posting),
+	}
+	for i := range docs {
+		for _, d := range docs[i] {
+			d.session = i
+			doc := len(index.docs)
+			index.docs = append(index.docs, d)
+
+			freqs := make(map[string]int)
+			for _, tok := range tokenize(d.text) {
+				freqs[tok.term]++
+			}
+			for term, freq := range freqs {
+				index.postings[term] = append(index.postings[term], posting{doc: doc, freq: freq})
+			}
+		}
+	}
+	return index, nil
+}
+
+// sessionSearchDocs extracts the searchable text of a session's entries along
+// with the branches and models the session recorded
+func sessionSearchDocs(entries []LogEntry) ([]string, []string, []searchDoc) {
+	var branches, models []string
+	seenBranches := make(map[string]bool)
+	seenModels := make(map[string]bool)
+	var docs []searchDoc
+
+	calls := toolCallsByID(pairToolCalls(entries))
+	for _, entry := range entries {
+		if entry.GitBranch != "" && !seenBranches[entry.GitBranch] {
+			seenBranches[entry.GitBranch] = true
+			branches = append(branches, entry.GitBranch)
+		}
+		model := ""
+		if entry.Message.Model != nil {
+			model = *entry.Message.Model
+			if !seenModels[model] {
+				seenModels[model] = true
+				models = append(models, model)
+			}
+		}
+
+		for _, field := range entrySearchFields(entry, calls) {
+			d := searchDoc{
+				toolUseID: field.ToolUseID,
+				entryUUID: entry.Uuid,
+				kind:      field.Kind,
+				tool:      field.Tool,
+				text:      field.Text,
+				entry: queryEntry{
+					Timestamp: entry.Timestamp,
+					Role:      entry.Message.Role,
+					GitBranch: entry.GitBranch,
+					Model:     model,
+				},
+			}
+			if call, ok := calls[field.ToolUseID]; ok && field.ToolUseID != "" {
+				d.entry.Tool = call.Use.Name
+				d.entry.File = toolInputFilePath(call.Use.Input)
+				d.entry.IsError = call.IsError()
+			}
+			docs = append(docs, d)
+		}
+	}
+	return branches, models, docs
+}
+
+// Search returns the entries matching a query along with the total number
+// of matches. Free text terms must all appear in an entry and are ranked by
+// TF-IDF; filter-only queries return matching entries newest first.
+func (idx *SearchIndex) Search(q Query) ([]SearchResult, int) {
+	terms := queryTerms(q.Text)
+	if len(terms) == 0 && !q.HasFilters() {
+		return nil, 0
+	}
+
+	sessionMatches := make([]bool, len(idx.sessions))
+	for i, session := range idx.sessions {
+		sessionMatches[i] = q.MatchSession(session.Project, session.branches, session.models)
+	}
+
+	scores := make(map[int]float64)
+	var docs []int
+
+	if len(terms) == 0 {
+		// A tool call's input and result match the same filters, so each call
+		// is listed once, by whichever block comes first
+		calls := make(map[string]bool)
+		for doc := range idx.docs {
+			if !idx.matchDoc(q, doc, sessionMatches) {
+				continue
+			}
+			if d := idx.docs[doc]; d.toolUseID != "" {
+				key := fmt.Sprintf("%d/%s", d.session, d.toolUseID)
+				if calls[key] {
+					continue
+				}
+				calls[key] = true
+			}
+			docs = append(docs, doc)
+		}
+		return idx.rankResults(docs, scores, terms)
+	}
+
+	matched := make(map[int]int)
+	for _, term := range terms {
+		postings := idx.postings[term]
+		if len(postings) == 0 {
+			return nil, 0
+		}
+		idf := math.Log(1 + float64(len(idx.docs))/float64(len(postings)))
+		for _, p := range postings {
+			scores[p.doc] += (1 + math.Log(float64(p.freq))) * idf
+			matched[p.doc]++
+		}
+	}
+
+	for doc, count := range matched {
+		if count == len(terms) && idx.matchDoc(q, doc, sessionMatches) {
+			docs = append(docs, doc)
+		}
+	}
+
+	return idx.rankResults(docs, scores, terms)
+}
+
+func (idx *SearchIndex) matchDoc(q Query, doc int, sessionMatches []bool) bool {
+	d := idx.docs[doc]
+	return sessionMatches[d.session] && q.MatchEntry(d.entry)
+}
+
+func (idx *SearchIndex) rankResults(docs []int, scores map[int]float64, terms []string) ([]SearchResult, int) {
+	sort.Slice(docs, func(i, j int) bool {
+		if scores[docs[i]] != scores[docs[j]] {
+			return scores[docs[i]] > scores[docs[j]]
+		}
+		return idx.docs[docs[i]].entry.Timestamp.After(idx.docs[docs[j]].entry.Timestamp)
+	})
+
+	total := len(docs)
+	if len(docs) > maxSearchResults {
+		docs = docs[:maxSearchResults]
+	}
+
+	results := make([]SearchResult, 0, len(docs))
+	for _, doc := range docs {
+		d := idx.docs[doc]
+		session := idx.sessions[d.session]
+		results = append(results, SearchResult{
+			Project:   session.Project,
+			Session:   session.Session,
+			EntryUUID: d.entryUUID,
+			Timestamp: d.entry.Timestamp,
+			Kind:      d.kind,
+			Tool:      d.tool,
+			Score:     scores[doc],
+			Snippet:   buildSnippet(d.text, terms),
+		})
+	}
+	return results, total
+}
+
+func queryTerms(query string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, tok := range tokenize(query) {
+		if !seen[tok.term] {
+			seen[tok.term] = true
+			terms = append(terms, tok.term)
+		}
+	}
+	return terms
+}
+
+// tokenize splits text into lowercased words, keeping their byte offsets so
+// matches can be highlighted in the original text
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+		if isWord && start < 0 {
+			start = i
+		} else if !isWord && start >= 0 {
+			tokens = appendToken(tokens, text, start, i)
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = appendToken(tokens, text, start, len(text))
+	}
+	return tokens
+}
+
+func appendToken(tokens []token, text string, start, end int) []token {
+	word := text[start:end]
+	if utf8.RuneCountInString(word) < 2 {
+		return tokens
+	}
+	return append(tokens, token{term: strings.ToLower(word), start: start, end: end})
+}
+
+// buildSnippet cuts a window of text around the first match and splits it
+// into highlighted and plain parts
+func buildSnippet(text string, terms []string) []SnippetPart {
+	wanted := make(map[string]bool)
+	for _, term := range terms {
+		wanted[term] = true
+	}
+
+	tokens := tokenize(text)
+	first := -1
+	for _, tok := range tokens {
+		if wanted[tok.term] {
+			first = tok.start
+			break
+		}
+	}
+	if first < 0 {
+		first = 0
+	}
+
+	start := runeBoundary(text, first-snippetContext)
+	end := runeBoundary(text, start+snippetLength)
+
+	var parts []SnippetPart
+	if start > 0 {
+		parts = append(parts, SnippetPart{Text: "…"})
+	}
+	pos := start
+	for _, tok := range tokens {
+		if tok.start < start || tok.end > end || !wanted[tok.term] {
+			continue
+		}
+		if tok.start > pos {
+			parts = append(parts, SnippetPart{Text: flattenWhitespace(text[pos:tok.start])})
+		}
+		parts = append(parts, SnippetPart{Text: text[tok.start:tok.end], Match: true})
+		pos = tok.end
+	}
+	if pos < end {
+		parts = append(parts, SnippetPart{Text: flattenWhitespace(text[pos:end])})
+	}
+	if end < len(text) {
+		parts = append(parts, SnippetPart{Text: "…"})
+	}
+	return parts
+}
+
+// runeBoundary clamps a byte offset to the text and moves it back to the
+// start of a UTF-8 sequence
+func runeBoundary(text string, offset int) int {
+	if offset <= 0 {
+		return 0
+	}
+	if offset >= len(text) {
+		return len(text)
+	}
+	for offset > 0 && !utf8.RuneStart(text[offset]) {
+		offset--
+	}
+	return offset
+}
+
+func flattenWhitespace(text string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ", "\t", " ").Replace(text)
+}
+
+// entrySearchFields extracts the user prompts, assistant text, tool inputs
+// and tool results of an entry
+func entrySearchFields(entry LogEntry, calls map[string]ToolCall) []searchField {
+	var fields []searchField
+
+	kind := "assistant"
+	if entry.Message.Role == "user" {
+		kind = "prompt"
+	}
+
+	switch content := entry.Message.Content.(type) {
+	case string:
+		if content != "" {
+			fields = append(fields, searchField{Kind: kind, Text: content})
+		}
+	case []ContentBlock:
+		for _, block := range content {
+			switch b := block.(type) {
+			case *TextBlock:
+				fields = append(fields, searchField{Kind: kind, Text: b.Text})
+			case *ToolUseBlock:
+				fields = append(fields, searchField{Kind: "tool_input", Tool: b.Name, ToolUseID: b.Id, Text: strings.Join(collectStrings(b.Input), "\n")})
+			case *ToolResultBlock:
+				tool := ""
+				if call, ok := calls[b.ToolUseId]; ok {
+					tool = call.Use.Name
+				}
+				fields = append(fields, searchField{Kind: "tool_result", Tool: tool, ToolUseID: b.ToolUseId, Text: strings.Join(collectStrings(b.Content), "\n")})
+			}
+		}
+	}
+
+	return fields
+}
+
+// collectStrings returns every string value found in a tool input or result
+func collectStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case nil, bool, float64:
+		return nil
+	case string:
+		return []string{v}
+	case []interface{}:
+		var out []string
+		for _, item := range v {
+			out = append(out, collectStrings(item)...)
+		}
+		return out
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var out []string
+		for _, key := range keys {
+			out = append(out, collectStrings(v[key])...)
+		}
+		return out
+	default:
+		// Typed tool inputs are converted to generic JSON values first
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		var generic interface{}
+		if err := json.Unmarshal(data, &generic); err != nil {
+			return nil
+		}
+		switch generic.(type) {
+		case map[string]interface{}, []interface{}:
+			return collectStrings(generic)
+		}
+		return nil
+	}
+}
+
+func searchKindLabel(kind, tool string) string {
+	switch kind {
+	case "prompt":
+		return "User prompt"
+	case "assistant":
+		return "Assistant"
+	case "tool_input":
+		return tool + " input"
+	case "tool_result":
+		if tool == "" {
+			return "Tool result"
+		}
+		return tool + " result"
+	}
+	return kind
+}
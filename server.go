@@ -1,97 +1,245 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 type ProjectInfo struct {
-	Name     string
-	Path     string
-	ModTime  time.Time
-	Sessions []SessionInfo
+	Root        DataRoot
+	Name        string
+	Path        string
+	Cwd         string
+	DisplayName string
+	RepoPath    string
+	ModTime     time.Time
+	Sessions    []SessionInfo
 }
 
 type SessionInfo struct {
 	UUID        string
+	Title       string
 	Filename    string
 	ModTime     time.Time
 	Size        int64
 	LatestTodos *TodoWriteInput
+	Usage       SessionUsage
+	Cache       CacheStats
+	Activity    SessionActivity
+	// Branches lists the git branches the session ran on, in order of use
+	Branches       []string
+	BranchSwitches int
+	ToolCalls      int
+	ToolErrors     int
+	// records and tools are the session's API responses and tool calls,
+	// kept for totals across sessions
+	records []usageRecord
+	tools   []toolRecord
 }
 
 func startServer(port string) {
 	http.HandleFunc("/", indexHandler)
 	http.HandleFunc("/project/", projectHandler)
 	http.HandleFunc("/session/", sessionHandler)
+	http.HandleFunc("/find/", findHandler)
+	http.HandleFunc("/dashboard", dashboardHandler)
+	http.HandleFunc("/blocks", blocksHandler)
+	http.HandleFunc("/errors", toolErrorsHandler)
+	http.HandleFunc("/latency", latencyHandler)
+	http.HandleFunc("/activity", activityHandler)
+	http.HandleFunc("/models", modelsHandler)
+	http.HandleFunc("/friction", frictionHandler)
+	http.HandleFunc("/metrics", metricsHandler)
+	http.HandleFunc("/branches/", branchesHandler)
+	http.HandleFunc("/churn/", churnHandler)
+	http.HandleFunc("/compare", compareHandler)
+	http.HandleFunc("/search", searchHandler)
+	http.HandleFunc("/file", fileHandler)
+	http.HandleFunc("/bash", bashHandler)
 	
 	fmt.Printf("Starting Claude Code Parser server on http://localhost:%s\n", port)
 	log.Fatal(http.ListenAndServe(":"+port, nil))
 }
 
 func indexHandler(w http.ResponseWriter, r *http.Request) {
-	projects, err := getClaudeProjects()
+	projects, err := getClaudeProjects(r.Context())
 	if err != nil {
+		if r.Context().Err() != nil {
+			// The browser went away; nothing to render
+			return
+		}
 		http.Error(w, fmt.Sprintf("Error reading projects: %v", err), http.StatusInternalServerError)
 		return
 	}
 	
-	component := ProjectsIndex(projects)
+	groupByRepo := r.URL.Query().Get("group") == "repo"
+	var leaderboard *ProjectLeaderboard
+	if r.URL.Query().Get("view") == "table" {
+		board := buildProjectLeaderboard(projects, r.URL.Query().Get("q"), r.URL.Query().Get("sort"), r.URL.Query().Get("order"))
+		leaderboard = &board
+	}
+	component := ProjectsIndex(roots, projects, groupByRepo, leaderboard)
 	if err := component.Render(r.Context(), w); err != nil {
 		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
 	}
 }
 
 func projectHandler(w http.ResponseWriter, r *http.Request) {
-	// Extract project name from URL: /project/my-project-name
-	projectName := strings.TrimPrefix(r.URL.Path, "/project/")
-	if projectName == "" {
+	// Extract root and project name from URL: /project/root-id/my-project-name
+	pathParts := strings.Split(strings.TrimPrefix(r.URL.Path, "/project/"), "/")
+	if len(pathParts) != 2 || pathParts[1] == "" {
 		http.Error(w, "Project name required", http.StatusBadRequest)
 		return
 	}
 	
-	claudeDir := os.ExpandEnv("$HOME/.claude/projects")
-	projectPath := filepath.Join(claudeDir, projectName)
+	root, projectName := pathParts[0], pathParts[1]
+	if _, ok := resolveProjectPath(root, projectName); !ok {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
 	
-	sessions, err := getProjectSessions(projectPath)
+	project, err := getProject(r.Context(), root, projectName)
 	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		if os.IsNotExist(err) {
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, fmt.Sprintf("Error reading project sessions: %v", err), http.StatusInternalServerError)
 		return
 	}
 	
-	component := ProjectDetail(projectName, sessions)
+	component := ProjectDetail(project)
 	if err := component.Render(r.Context(), w); err != nil {
 		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
 	}
 }
 
-func sessionHandler(w http.ResponseWriter, r *http.Request) {
-	// Extract project and session from URL: /session/project-name/session-uuid
-	pathParts := strings.Split(strings.TrimPrefix(r.URL.Path, "/session/"), "/")
-	if len(pathParts) != 2 {
-		http.Error(w, "Invalid session URL format", http.StatusBadRequest)
+func frictionHandler(w http.ResponseWriter, r *http.Request) {
+	report, err := getFrictionReport(r.Context())
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error reading sessions: %v", err), http.StatusInternalServerError)
 		return
 	}
 	
-	projectName, sessionUUID := pathParts[0], pathParts[1]
+	component := FrictionPage(report)
+	if err := component.Render(r.Context(), w); err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func compareHandler(w http.ResponseWriter, r *http.Request) {
+	a := strings.TrimSpace(r.URL.Query().Get("a"))
+	b := strings.TrimSpace(r.URL.Query().Get("b"))
 	
-	claudeDir := os.ExpandEnv("$HOME/.claude/projects")
-	sessionPath := filepath.Join(claudeDir, projectName, sessionUUID+".jsonl")
+	var comparison *Comparison
+	var errorMessage string
+	if a != "" && b != "" {
+		result, err := getComparison(r.Context(), a, b)
+		if err != nil {
+			if r.Context().Err() != nil {
+				return
+			}
+			errorMessage = err.Error()
+		} else {
+			comparison = &result
+		}
+	}
 	
-	// Check if file exists
-	if _, err := os.Stat(sessionPath); os.IsNotExist(err) {
-		http.Error(w, "Session not found", http.StatusNotFound)
+	component := ComparePage(a, b, comparison, errorMessage)
+	if err := component.Render(r.Context(), w); err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func branchesHandler(w http.ResponseWriter, r *http.Request) {
+	pathParts := strings.Split(strings.TrimPrefix(r.URL.Path, "/branches/"), "/")
+	if len(pathParts) != 2 || pathParts[1] == "" {
+		http.Error(w, "Project name required", http.StatusBadRequest)
+		return
+	}
+	
+	root, projectName := pathParts[0], pathParts[1]
+	if _, ok := resolveProjectPath(root, projectName); !ok {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	branches, err := getProjectBranches(r.Context(), root, projectName)
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		if os.IsNotExist(err) {
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error reading project sessions: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	component := BranchesPage(branches)
+	if err := component.Render(r.Context(), w); err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func churnHandler(w http.ResponseWriter, r *http.Request) {
+	pathParts := strings.Split(strings.TrimPrefix(r.URL.Path, "/churn/"), "/")
+	if len(pathParts) != 2 || pathParts[1] == "" {
+		http.Error(w, "Project name required", http.StatusBadRequest)
+		return
+	}
+	
+	root, projectName := pathParts[0], pathParts[1]
+	if _, ok := resolveProjectPath(root, projectName); !ok {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	
+	churn, err := getProjectChurn(r.Context(), root, projectName, r.URL.Query().Get("sort"))
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		if os.IsNotExist(err) {
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error reading project sessions: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	component := ChurnPage(churn)
+	if err := component.Render(r.Context(), w); err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func sessionHandler(w http.ResponseWriter, r *http.Request) {
+	sessionPath, ok := sessionPathFromURL(w, r, "/session/")
+	if !ok {
 		return
 	}
 	
 	// Parse the JSONL file
-	entries, err := parseJSONL(sessionPath)
+	entries, err := loadSessionEntries(sessionPath)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error parsing session: %v", err), http.StatusInternalServerError)
 		return
@@ -104,10 +252,323 @@ func sessionHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func getClaudeProjects() ([]ProjectInfo, error) {
-	claudeDir := os.ExpandEnv("$HOME/.claude/projects")
+func searchHandler(w http.ResponseWriter, r *http.Request) {
+	input := strings.TrimSpace(r.URL.Query().Get("q"))
 	
-	entries, err := os.ReadDir(claudeDir)
+	var results []SearchResult
+	total := 0
+	queryError := ""
+	if input != "" {
+		query, err := ParseQuery(input, time.Now())
+		if err != nil {
+			queryError = err.Error()
+		} else {
+			index, err := getSearchIndex(r.Context())
+			if err != nil {
+				if r.Context().Err() != nil {
+					return
+				}
+				http.Error(w, fmt.Sprintf("Error building search index: %v", err), http.StatusInternalServerError)
+				return
+			}
+			results, total = index.Search(query)
+		}
+	}
+	
+	component := SearchPage(input, results, total, queryError)
+	if err := component.Render(r.Context(), w); err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func fileHandler(w http.ResponseWriter, r *http.Request) {
+	filePath := strings.TrimSpace(r.URL.Query().Get("path"))
+	
+	var touches []FileTouch
+	if filePath != "" {
+		var err error
+		touches, err = getFileHistory(r.Context(), filePath)
+		if err != nil {
+			if r.Context().Err() != nil {
+				return
+			}
+			http.Error(w, fmt.Sprintf("Error reading sessions: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	
+	component := FileHistoryPage(filePath, touches)
+	if err := component.Render(r.Context(), w); err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func bashHandler(w http.ResponseWriter, r *http.Request) {
+	filter := strings.TrimSpace(r.URL.Query().Get("q"))
+	
+	records, err := getBashHistory(r.Context())
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error reading sessions: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	component := BashHistoryPage(filter, filterBashHistory(records, filter))
+	if err := component.Render(r.Context(), w); err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func dashboardHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, to := dashboardRange(query.Get("from"), query.Get("to"), time.Now())
+	
+	dashboard, err := getDashboard(r.Context(), from, to, query.Get("by"))
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error reading sessions: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	component := DashboardPage(dashboard)
+	if err := component.Render(r.Context(), w); err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func blocksHandler(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	blocks, err := getUsageBlocks(r.Context(), now)
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error reading sessions: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	component := BlocksPage(blocks, now)
+	if err := component.Render(r.Context(), w); err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func toolErrorsHandler(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	sinceValue := strings.TrimSpace(r.URL.Query().Get("since"))
+	var since time.Time
+	if sinceValue != "" {
+		t, err := parseQueryTime(sinceValue, now, false)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		since = t
+	}
+	
+	report, err := getToolErrorReport(r.Context(), since, now)
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error reading sessions: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	component := ToolErrorsPage(report, sinceValue)
+	if err := component.Render(r.Context(), w); err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func latencyHandler(w http.ResponseWriter, r *http.Request) {
+	report, err := getLatencyReport(r.Context())
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error reading sessions: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	component := LatencyPage(report)
+	if err := component.Render(r.Context(), w); err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func activityHandler(w http.ResponseWriter, r *http.Request) {
+	weeks := defaultActivityWeeks
+	if n, err := strconv.Atoi(r.URL.Query().Get("weeks")); err == nil && n > 0 && n <= 104 {
+		weeks = n
+	}
+
+	activity, err := getWeeklyActivity(r.Context(), weeks, time.Now())
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error reading sessions: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	component := ActivityPage(activity)
+	if err := component.Render(r.Context(), w); err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func modelsHandler(w http.ResponseWriter, r *http.Request) {
+	breakdown, err := getResponseBreakdown(r.Context())
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error reading sessions: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	component := ModelsPage(breakdown)
+	if err := component.Render(r.Context(), w); err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// findHandler answers the find box on a session page with the number of
+// matches in each entry, including content in collapsed sections
+func findHandler(w http.ResponseWriter, r *http.Request) {
+	sessionPath, ok := sessionPathFromURL(w, r, "/find/")
+	if !ok {
+		return
+	}
+	
+	entries, err := loadSessionEntries(sessionPath)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error parsing session: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	result := findInSession(entries, r.URL.Query().Get("q"))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Printf("Error writing find results: %v", err)
+	}
+}
+
+// sessionPathFromURL extracts root, project and session from a URL such as
+// /session/root-id/project-name/session-uuid and returns the session file,
+// writing an error response when it cannot be found
+func sessionPathFromURL(w http.ResponseWriter, r *http.Request, prefix string) (string, bool) {
+	pathParts := strings.Split(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	if len(pathParts) != 3 {
+		http.Error(w, "Invalid session URL format", http.StatusBadRequest)
+		return "", false
+	}
+	
+	root, projectName, sessionUUID := pathParts[0], pathParts[1], pathParts[2]
+	
+	projectPath, ok := resolveProjectPath(root, projectName)
+	if !ok || !isValidUUID(sessionUUID) {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return "", false
+	}
+	sessionPath := filepath.Join(projectPath, sessionUUID+".jsonl")
+	
+	// Check if file exists
+	if _, err := os.Stat(sessionPath); os.IsNotExist(err) {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return "", false
+	}
+	
+	return sessionPath, true
+}
+
+// resolveProjectPath maps a root ID and project directory name from a URL to
+// a directory on disk, rejecting names that would escape the root
+func resolveProjectPath(rootID, projectName string) (string, bool) {
+	root, ok := findRoot(rootID)
+	if !ok {
+		return "", false
+	}
+	if projectName == "" || projectName == "." || projectName == ".." || strings.ContainsAny(projectName, "/\\") {
+		return "", false
+	}
+	return filepath.Join(root.Path, projectName), true
+}
+
+// getClaudeProjects lists the projects in every data root. Directory listings
+// are read first, then session metadata and project locations are loaded on
+// a bounded pool of workers so that large installs are scanned in parallel.
+func getClaudeProjects(ctx context.Context) ([]ProjectInfo, error) {
+	var projects []ProjectInfo
+	var lastErr error
+	readable := 0
+	
+	for _, root := range roots {
+		rootProjects, err := getRootProjects(root)
+		if err != nil {
+			log.Printf("Skipping data root %s: %v", root.Label, err)
+			lastErr = err
+			continue
+		}
+		readable++
+		projects = append(projects, rootProjects...)
+	}
+	
+	if readable == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	
+	listed := make(map[string]bool)
+	for _, project := range projects {
+		for _, session := range project.Sessions {
+			listed[filepath.Join(project.Path, session.Filename)] = true
+		}
+	}
+	pruneSessionCaches(listed)
+	
+	// One task per project to resolve its location, plus one per session
+	type scanTask struct {
+		project int
+		session int
+	}
+	var tasks []scanTask
+	for i := range projects {
+		tasks = append(tasks, scanTask{project: i, session: -1})
+		for j := range projects[i].Sessions {
+			tasks = append(tasks, scanTask{project: i, session: j})
+		}
+	}
+	
+	err := forEachParallel(ctx, scanWorkers, len(tasks), func(i int) {
+		task := tasks[i]
+		project := &projects[task.project]
+		if task.session < 0 {
+			resolveProjectLocation(project)
+		} else {
+			loadSessionMetadata(project.Path, &project.Sessions[task.session])
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	// Sort by modification time (most recent first)
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].ModTime.After(projects[j].ModTime)
+	})
+	
+	return projects, nil
+}
+
+// getRootProjects lists the project directories of a data root and their
+// session files without reading the sessions themselves
+func getRootProjects(root DataRoot) ([]ProjectInfo, error) {
+	entries, err := os.ReadDir(root.Path)
 	if err != nil {
 		return nil, fmt.Errorf("error reading Claude projects directory: %v", err)
 	}
@@ -121,14 +582,15 @@ func getClaudeProjects() ([]ProjectInfo, error) {
 				continue
 			}
 			
-			projectPath := filepath.Join(claudeDir, entry.Name())
-			sessions, err := getProjectSessions(projectPath)
+			projectPath := filepath.Join(root.Path, entry.Name())
+			sessions, err := listProjectSessions(projectPath)
 			if err != nil {
 				// Skip projects we can't read
 				continue
 			}
 			
 			projects = append(projects, ProjectInfo{
+				Root:     root,
 				Name:     entry.Name(),
 				Path:     projectPath,
 				ModTime:  info.ModTime(),
@@ -137,15 +599,62 @@ func getClaudeProjects() ([]ProjectInfo, error) {
 		}
 	}
 	
-	// Sort by modification time (most recent first)
-	sort.Slice(projects, func(i, j int) bool {
-		return projects[i].ModTime.After(projects[j].ModTime)
+	return projects, nil
+}
+
+func getProject(ctx context.Context, rootID, projectName string) (ProjectInfo, error) {
+	root, _ := findRoot(rootID)
+	projectPath := filepath.Join(root.Path, projectName)
+	
+	info, err := os.Stat(projectPath)
+	if err != nil {
+		return ProjectInfo{}, err
+	}
+	
+	sessions, err := getProjectSessions(ctx, projectPath)
+	if err != nil {
+		return ProjectInfo{}, err
+	}
+	
+	project := ProjectInfo{
+		Root:     root,
+		Name:     projectName,
+		Path:     projectPath,
+		ModTime:  info.ModTime(),
+		Sessions: sessions,
+	}
+	resolveProjectLocation(&project)
+	return project, nil
+}
+
+func projectsInRoot(projects []ProjectInfo, rootID string) []ProjectInfo {
+	var filtered []ProjectInfo
+	for _, project := range projects {
+		if project.Root.ID == rootID {
+			filtered = append(filtered, project)
+		}
+	}
+	return filtered
+}
+
+// getProjectSessions lists the sessions of a project and loads their metadata
+func getProjectSessions(ctx context.Context, projectPath string) ([]SessionInfo, error) {
+	sessions, err := listProjectSessions(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	
+	err = forEachParallel(ctx, scanWorkers, len(sessions), func(i int) {
+		loadSessionMetadata(projectPath, &sessions[i])
 	})
+	if err != nil {
+		return nil, err
+	}
 	
-	return projects, nil
+	return sessions, nil
 }
 
-func getProjectSessions(projectPath string) ([]SessionInfo, error) {
+func listProjectSessions(projectPath string) ([]SessionInfo, error) {
 	entries, err := os.ReadDir(projectPath)
 	if err != nil {
 		return nil, err
@@ -178,34 +687,101 @@ func getProjectSessions(projectPath string) ([]SessionInfo, error) {
 		return sessions[i].ModTime.After(sessions[j].ModTime)
 	})
 	
-	// For the first 5 sessions (most recent), get the latest TodoWrite
-	for i := 0; i < len(sessions) && i < 5; i++ {
-		sessionPath := filepath.Join(projectPath, sessions[i].Filename)
-		sessions[i].LatestTodos = getLatestTodoWrite(sessionPath)
-	}
-	
 	return sessions, nil
 }
 
+// loadSessionMetadata reads the title, latest TodoWrite, usage, cache
+// statistics, active time and git branches of a session
+func loadSessionMetadata(projectPath string, session *SessionInfo) {
+	sessionPath := filepath.Join(projectPath, session.Filename)
+	session.Title = readSessionTitle(sessionPath)
+	session.LatestTodos = getLatestTodoWrite(sessionPath)
+	records := readSessionUsageRecords(sessionPath)
+	session.records = records
+	session.Usage = summarizeUsage(records)
+	session.Cache = cacheStats(records)
+	session.Activity = sessionActivity(readSessionTimestamps(sessionPath))
+	session.Branches, session.BranchSwitches = branchSequence(readSessionBranches(sessionPath))
+	session.tools = readSessionToolRecords(sessionPath)
+	session.ToolCalls, session.ToolErrors = countToolRecords(session.tools)
+}
+
 func isValidUUID(uuid string) bool {
 	return len(uuid) == 36 &&
 		uuid[8] == '-' && uuid[13] == '-' &&
 		uuid[18] == '-' && uuid[23] == '-'
 }
 
+type cachedTodos struct {
+	modTime time.Time
+	size    int64
+	todos   *TodoWriteInput
+}
+
+// todoCache keeps the latest TodoWrite of each session until the file changes
+var todoCache = struct {
+	sync.Mutex
+	sessions map[string]cachedTodos
+}{sessions: make(map[string]cachedTodos)}
+
+// getLatestTodoWrite returns the input of the last TodoWrite call in a
+// session, reusing the previous result when the file has not changed
 func getLatestTodoWrite(sessionPath string) *TodoWriteInput {
-	entries, err := parseJSONL(sessionPath)
+	info, err := os.Stat(sessionPath)
+	if err != nil {
+		return nil
+	}
+	
+	todoCache.Lock()
+	cached, ok := todoCache.sessions[sessionPath]
+	todoCache.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.todos
+	}
+	
+	todos := readLatestTodoWrite(sessionPath)
+	todoCache.Lock()
+	todoCache.sessions[sessionPath] = cachedTodos{modTime: info.ModTime(), size: info.Size(), todos: todos}
+	todoCache.Unlock()
+	return todos
+}
+
+// readLatestTodoWrite reads a session file backwards so only the tail after
+// its last TodoWrite call is parsed
+func readLatestTodoWrite(sessionPath string) *TodoWriteInput {
+	file, err := os.Open(sessionPath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	
+	reader, err := newReverseLineReader(file)
 	if err != nil {
 		return nil
 	}
 	
-	// Look through entries in reverse order (most recent first)
-	for i := len(entries) - 1; i >= 0; i-- {
-		entry := entries[i]
+	for {
+		line, err := reader.ReadLine()
+		if err != nil {
+			return nil
+		}
+		
+		// Skip the JSON decoding for lines that cannot contain a TodoWrite call
+		if !bytes.Contains(line, []byte(`"TodoWrite"`)) {
+			continue
+		}
+		
+		var entry LogEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		if err := parseMessageContent(&entry.Message); err != nil {
+			continue
+		}
 		
 		if blocks, ok := entry.Message.Content.([]ContentBlock); ok {
-			for _, block := range blocks {
-				if toolUse, ok := block.(*ToolUseBlock); ok {
+			for i := len(blocks) - 1; i >= 0; i-- {
+				if toolUse, ok := blocks[i].(*ToolUseBlock); ok {
 					if toolUse.Name == "TodoWrite" {
 						if todoInput, ok := toolUse.Input.(TodoWriteInput); ok {
 							return &todoInput
@@ -215,6 +791,4 @@ func getLatestTodoWrite(sessionPath string) *TodoWriteInput {
 			}
 		}
 	}
-	
-	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// LoadedSession is a parsed session together with the project it belongs to
+type LoadedSession struct {
+	Project ProjectInfo
+	Session SessionInfo
+	Path    string
+	Entries []LogEntry
+}
+
+// sessionCacheLimit bounds the total size of the session files whose parsed
+// entries are kept in memory
+var sessionCacheLimit int64 = 512 << 20
+
+type cachedSession struct {
+	path    string
+	modTime time.Time
+	size    int64
+	entries []LogEntry
+}
+
+// sessionCache keeps parsed session files in memory until they change on
+// disk, evicting the least recently used ones beyond sessionCacheLimit
+var sessionCache = struct {
+	sync.Mutex
+	sessions map[string]*list.Element
+	// recent orders the cached sessions, most recently used first
+	recent *list.List
+	size   int64
+}{sessions: make(map[string]*list.Element), recent: list.New()}
+
+// loadSessionEntries parses a session file, reusing the previous result when
+// the file has not been modified since
+func loadSessionEntries(sessionPath string) ([]LogEntry, error) {
+	info, err := os.Stat(sessionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionCache.Lock()
+	if element, ok := sessionCache.sessions[sessionPath]; ok {
+		cached := element.Value.(cachedSession)
+		if cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+			sessionCache.recent.MoveToFront(element)
+			sessionCache.Unlock()
+			serverMetrics.sessionCacheHits.Add(1)
+			return cached.entries, nil
+		}
+	}
+	sessionCache.Unlock()
+	serverMetrics.sessionCacheMisses.Add(1)
+
+	start := time.Now()
+	entries, err := parseJSONL(sessionPath)
+	serverMetrics.parseDuration.since(start)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionCache.Lock()
+	defer sessionCache.Unlock()
+	removeCachedSession(sessionPath)
+	sessionCache.sessions[sessionPath] = sessionCache.recent.PushFront(cachedSession{
+		path:    sessionPath,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		entries: entries,
+	})
+	sessionCache.size += info.Size()
+	// The session just parsed stays even when it alone exceeds the limit
+	for sessionCache.size > sessionCacheLimit && sessionCache.recent.Len() > 1 {
+		removeCachedSession(sessionCache.recent.Back().Value.(cachedSession).path)
+	}
+
+	return entries, nil
+}
+
+// removeCachedSession drops a session from the cache. The cache must be
+// locked.
+func removeCachedSession(sessionPath string) {
+	element, ok := sessionCache.sessions[sessionPath]
+	if !ok {
+		return
+	}
+	sessionCache.size -= element.Value.(cachedSession).size
+	sessionCache.recent.Remove(element)
+	delete(sessionCache.sessions, sessionPath)
+}
+
+// pruneSessionCaches forgets the parsed entries, scans and todos of session
+// files that are no longer listed, such as deleted sessions
+func pruneSessionCaches(listed map[string]bool) {
+	sessionCache.Lock()
+	for sessionPath := range sessionCache.sessions {
+		if !listed[sessionPath] {
+			removeCachedSession(sessionPath)
+		}
+	}
+	sessionCache.Unlock()
+
+	scanCache.Lock()
+	for sessionPath := range scanCache.sessions {
+		if !listed[sessionPath] {
+			delete(scanCache.sessions, sessionPath)
+		}
+	}
+	scanCache.Unlock()
+
+	todoCache.Lock()
+	for sessionPath := range todoCache.sessions {
+		if !listed[sessionPath] {
+			delete(todoCache.sessions, sessionPath)
+		}
+	}
+	todoCache.Unlock()
+}
+
+// listAllSessions lists the session files of every data root, with their
+// projects resolved, without reading the sessions
+func listAllSessions() []LoadedSession {
+	var sessions []LoadedSession
+	listed := make(map[string]bool)
+
+	for _, root := range roots {
+		projects, err := getRootProjects(root)
+		if err != nil {
+			continue
+		}
+		for _, project := range projects {
+			resolveProjectLocation(&project)
+			for _, session := range project.Sessions {
+				sessions = append(sessions, LoadedSession{
+					Project: project,
+					Session: session,
+					Path:    filepath.Join(project.Path, session.Filename),
+				})
+				listed[sessions[len(sessions)-1].Path] = true
+			}
+		}
+	}
+	pruneSessionCaches(listed)
+
+	return sessions
+}
+
+// loadAllSessions parses every session in every data root on the scan
+// worker pool. Sessions that fail to parse are skipped.
+func loadAllSessions(ctx context.Context) ([]LoadedSession, error) {
+	return parseSessions(ctx, listAllSessions())
+}
+
+// loadProjectSessions parses every session of one project
+func loadProjectSessions(ctx context.Context, project ProjectInfo) ([]LoadedSession, error) {
+	var sessions []LoadedSession
+	for _, session := range project.Sessions {
+		sessions = append(sessions, LoadedSession{
+			Project: project,
+			Session: session,
+			Path:    filepath.Join(project.Path, session.Filename),
+		})
+	}
+	return parseSessions(ctx, sessions)
+}
+
+// parseSessions parses listed sessions on the scan worker pool and fills in
+// their metadata
+func parseSessions(ctx context.Context, sessions []LoadedSession) ([]LoadedSession, error) {
+	err := forEachParallel(ctx, scanWorkers, len(sessions), func(i int) {
+		entries, err := loadSessionEntries(sessions[i].Path)
+		if err != nil {
+			return
+		}
+		sessions[i].Entries = entries
+		sessions[i].Session.Title = getSessionTitle(entries)
+		records := usageRecords(entries)
+		sessions[i].Session.records = records
+		sessions[i].Session.Usage = summarizeUsage(records)
+		sessions[i].Session.Cache = cacheStats(records)
+		sessions[i].Session.Activity = entriesActivity(entries)
+		sessions[i].Session.Branches, sessions[i].Session.BranchSwitches = branchSequence(entriesBranches(entries))
+		sessions[i].Session.tools = readSessionToolRecords(sessions[i].Path)
+		sessions[i].Session.ToolCalls, sessions[i].Session.ToolErrors = countToolRecords(sessions[i].Session.tools)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
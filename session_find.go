@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+)
+
+// FindResult reports where a find query matched within a session
+type FindResult struct {
+	Query   string       `json:"query"`
+	Total   int          `json:"total"`
+	Entries []EntryMatch `json:"entries"`
+}
+
+// EntryMatch is the number of matches within one entry of the session page
+type EntryMatch struct {
+	ID    string `json:"id"`
+	Count int    `json:"count"`
+}
+
+// findInSession counts case-insensitive occurrences of the query in every
+// entry, covering the same content the session page renders: message text,
+// tool inputs, tool results and the tool use result metadata
+func findInSession(entries []LogEntry, query string) FindResult {
+	result := FindResult{Query: query, Entries: []EntryMatch{}}
+	needle := strings.ToLower(strings.TrimSpace(query))
+	if needle == "" {
+		return result
+	}
+
+	calls := toolCallsByID(pairToolCalls(entries))
+	for _, entry := range entries {
+		var texts []string
+		for _, field := range entrySearchFields(entry, calls) {
+			texts = append(texts, field.Text)
+		}
+		texts = append(texts, collectStrings(entry.ToolUseResult)...)
+		if entry.Type == "summary" {
+			texts = append(texts, entry.Summary)
+		}
+
+		count := 0
+		for _, text := range texts {
+			count += strings.Count(strings.ToLower(text), needle)
+		}
+		if count > 0 && entry.Uuid != "" {
+			result.Entries = append(result.Entries, EntryMatch{ID: "entry-" + entry.Uuid, Count: count})
+			result.Total += count
+		}
+	}
+
+	return result
+}
+
+// findURL returns the find endpoint for a session file, or an empty string
+// when the file is not inside a data root
+func findURL(sessionPath string) string {
+	sessionUUID := extractSessionUUID(sessionPath)
+	root, projectName, ok := locateSession(sessionPath)
+	if sessionUUID == "" || !ok {
+		return ""
+	}
+	return "/find" + strings.TrimPrefix(sessionURL(root.ID, projectName, sessionUUID), "/session")
+}
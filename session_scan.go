@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// usageLine holds the fields of a log line needed for its usage record
+type usageLine struct {
+	Uuid        string    `json:"uuid"`
+	ParentUuid  string    `json:"parentUuid"`
+	Timestamp   time.Time `json:"timestamp"`
+	IsSidechain bool      `json:"isSidechain"`
+	GitBranch   string    `json:"gitBranch"`
+	Message     struct {
+		Id         *string `json:"id"`
+		Model      *string `json:"model"`
+		StopReason *string `json:"stop_reason"`
+		Usage      *Usage  `json:"usage"`
+	} `json:"message"`
+}
+
+// sessionScan is what a lightweight scan of a session file extracts: the
+// usage of each response, the timestamp of every entry, the git branch of
+// every entry that recorded one, and every tool call
+type sessionScan struct {
+	modTime    time.Time
+	size       int64
+	records    []usageRecord
+	timestamps []time.Time
+	branches   []string
+	tools      []toolRecord
+}
+
+// toolRecord is a tool call found by the scan and whether its result was an
+// error
+type toolRecord struct {
+	ID    string
+	Name  string
+	Error bool
+}
+
+// toolLine is the part of a log line needed to count tool calls
+type toolLine struct {
+	Message struct {
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+type toolLineBlock struct {
+	Type      string `json:"type"`
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	ToolUseId string `json:"tool_use_id"`
+	IsError   bool   `json:"is_error"`
+}
+
+// scanCache keeps the scan of each session file until the file changes
+var scanCache = struct {
+	sync.Mutex
+	sessions map[string]sessionScan
+}{sessions: make(map[string]sessionScan)}
+
+// readSessionUsageRecords scans a session file for usage without parsing
+// message content
+func readSessionUsageRecords(sessionPath string) []usageRecord {
+	return scanSessionFile(sessionPath).records
+}
+
+// readSessionTimestamps returns the timestamp of every entry in a session
+// file, in file order
+func readSessionTimestamps(sessionPath string) []time.Time {
+	return scanSessionFile(sessionPath).timestamps
+}
+
+// readSessionBranches returns the git branch of every entry in a session
+// file that recorded one, in file order
+func readSessionBranches(sessionPath string) []string {
+	return scanSessionFile(sessionPath).branches
+}
+
+// readSessionToolRecords returns the tool calls of a session file in the
+// order they were made
+func readSessionToolRecords(sessionPath string) []toolRecord {
+	return scanSessionFile(sessionPath).tools
+}
+
+// countToolRecords returns how many tool calls there are and how many of them
+// failed
+func countToolRecords(tools []toolRecord) (int, int) {
+	errors := 0
+	for _, tool := range tools {
+		if tool.Error {
+			errors++
+		}
+	}
+	return len(tools), errors
+}
+
+// uniqueToolRecords returns the tool calls of each session without the calls
+// an older session already made, as uniqueRecords does for responses
+func uniqueToolRecords(sessions []SessionInfo) [][]toolRecord {
+	unique := make([][]toolRecord, len(sessions))
+	seen := make(map[string]bool)
+	for _, i := range sessionsByAge(sessions) {
+		for _, tool := range sessions[i].tools {
+			if tool.ID != "" {
+				if seen[tool.ID] {
+					continue
+				}
+				seen[tool.ID] = true
+			}
+			unique[i] = append(unique[i], tool)
+		}
+	}
+	return unique
+}
+
+// scanSessionFile reads what a sessionScan holds from a session file, caching
+// the result until the file changes. Timestamps and branches are found
+// without decoding; only lines carrying usage or tool blocks are decoded,
+// and of those only the fields needed.
+func scanSessionFile(sessionPath string) sessionScan {
+	info, err := os.Stat(sessionPath)
+	if err != nil {
+		return sessionScan{}
+	}
+
+	scanCache.Lock()
+	cached, ok := scanCache.sessions[sessionPath]
+	scanCache.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		serverMetrics.scanCacheHits.Add(1)
+		return cached
+	}
+	serverMetrics.scanCacheMisses.Add(1)
+	defer serverMetrics.scanDuration.since(time.Now())
+
+	file, err := os.Open(sessionPath)
+	if err != nil {
+		return sessionScan{}
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 10*1024*1024)
+
+	scan := sessionScan{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+	}
+	toolCalls := make(map[string]int)
+	var entries []LogEntry
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if value, ok := lineStringField(line, "timestamp"); ok {
+			if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+				scan.timestamps = append(scan.timestamps, t)
+			}
+		}
+		if branch, ok := lineStringField(line, "gitBranch"); ok && branch != "" {
+			scan.branches = append(scan.branches, branch)
+		}
+		if bytes.Contains(line, []byte(`"tool_use"`)) || bytes.Contains(line, []byte(`"tool_result"`)) {
+			scan.countTools(line, toolCalls)
+		}
+		if !bytes.Contains(line, []byte(`"usage"`)) {
+			// Subagent entries without usage still link the chain of the
+			// responses after them
+			if lineBoolField(line, "isSidechain") {
+				uuid, _ := lineStringField(line, "uuid")
+				parent, _ := lineStringField(line, "parentUuid")
+				entries = append(entries, LogEntry{Uuid: uuid, ParentUuid: parent, IsSidechain: true})
+			}
+			continue
+		}
+		var parsed usageLine
+		if err := json.Unmarshal(line, &parsed); err != nil {
+			continue
+		}
+		entries = append(entries, LogEntry{
+			Uuid:        parsed.Uuid,
+			ParentUuid:  parsed.ParentUuid,
+			Timestamp:   parsed.Timestamp,
+			IsSidechain: parsed.IsSidechain,
+			GitBranch:   parsed.GitBranch,
+			Message: Message{
+				Id:         parsed.Message.Id,
+				Model:      parsed.Message.Model,
+				StopReason: parsed.Message.StopReason,
+				Usage:      parsed.Message.Usage,
+			},
+		})
+	}
+	scan.records = usageRecords(entries)
+
+	scanCache.Lock()
+	scanCache.sessions[sessionPath] = scan
+	scanCache.Unlock()
+
+	return scan
+}
+
+// countTools records the tool calls and failed tool results of a log line.
+// toolCalls maps the tool use IDs seen so far to their record, or to -1 once
+// answered, so each call is counted once even when its message is logged
+// repeatedly.
+func (scan *sessionScan) countTools(line []byte, toolCalls map[string]int) {
+	var parsed toolLine
+	if err := json.Unmarshal(line, &parsed); err != nil {
+		return
+	}
+	var blocks []toolLineBlock
+	if err := json.Unmarshal(parsed.Message.Content, &blocks); err != nil {
+		return
+	}
+	for _, block := range blocks {
+		switch block.Type {
+		case "tool_use":
+			if _, seen := toolCalls[block.Id]; !seen {
+				toolCalls[block.Id] = len(scan.tools)
+				scan.tools = append(scan.tools, toolRecord{ID: block.Id, Name: block.Name})
+			}
+		case "tool_result":
+			// Only the first result of a call counts, as in pairToolCalls
+			if i, ok := toolCalls[block.ToolUseId]; ok && i >= 0 {
+				scan.tools[i].Error = block.IsError
+				toolCalls[block.ToolUseId] = -1
+			}
+		}
+	}
+}
+
+// lineStringField finds a string field of a log line's top-level object
+// without decoding the line. Values containing escapes are not supported,
+// which timestamps, branch names and UUIDs never need.
+func lineStringField(line []byte, name string) (string, bool) {
+	value := topLevelValue(line, name)
+	if len(value) == 0 || value[0] != '"' {
+		return "", false
+	}
+	end := bytes.IndexByte(value[1:], '"')
+	if end < 0 {
+		return "", false
+	}
+	return string(value[1 : end+1]), true
+}
+
+// lineBoolField reports whether a boolean field of a log line's top-level
+// object is true, without decoding the line
+func lineBoolField(line []byte, name string) bool {
+	return bytes.HasPrefix(topLevelValue(line, name), []byte("true"))
+}
+
+// topLevelValue returns the text following a key of a log line's top-level
+// object. Message content, tool input and tool results are logged before
+// the top-level keys and can use the same names, so keys of nested objects
+// are skipped.
+func topLevelValue(line []byte, name string) []byte {
+	depth := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		case '"':
+			end := i + 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil
+			}
+			if depth == 1 && string(line[i+1:end]) == name {
+				rest := bytes.TrimLeft(line[end+1:], " ")
+				if len(rest) > 0 && rest[0] == ':' {
+					return bytes.TrimLeft(rest[1:], " ")
+				}
+			}
+			i = end
+		}
+	}
+	return nil
+}
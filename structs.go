@@ -17,6 +17,26 @@ type LogEntry struct {
 	Uuid         string      `json:"uuid"`
 	Cwd          string      `json:"cwd"`
 	Type         string      `json:"type"`
+	IsMeta       bool        `json:"isMeta,omitempty"`
+	// IsApiErrorMessage marks the synthetic assistant message Claude Code
+	// writes when an API request fails
+	IsApiErrorMessage bool   `json:"isApiErrorMessage,omitempty"`
+	
+	// Summary entries only
+	Summary      string      `json:"summary,omitempty"`
+	LeafUuid     string      `json:"leafUuid,omitempty"`
+	
+	// System entries, e.g. the compact_boundary written when the
+	// conversation is compacted
+	Subtype          string           `json:"subtype,omitempty"`
+	CompactMetadata  *CompactMetadata `json:"compactMetadata,omitempty"`
+	IsCompactSummary bool             `json:"isCompactSummary,omitempty"`
+}
+
+// CompactMetadata describes a conversation compaction
+type CompactMetadata struct {
+	Trigger   string `json:"trigger"`
+	PreTokens int    `json:"preTokens"`
 }
 
 // Message can be either user or assistant message
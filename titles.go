@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const maxTitleLength = 80
+
+var systemReminderPattern = regexp.MustCompile(`(?s)<system-reminder>.*?</system-reminder>`)
+
+// noisePromptPrefixes mark user messages that are generated by Claude Code
+// rather than typed by the user
+var noisePromptPrefixes = []string{
+	"<command-name>",
+	"<command-message>",
+	"<command-args>",
+	"<local-command-stdout>",
+	"<local-command-stderr>",
+	"<bash-input>",
+	"<bash-stdout>",
+	"<user-memory-input>",
+	"Caveat:",
+	"[Request interrupted",
+	"This session is being continued from a previous conversation",
+}
+
+// readSessionTitle derives a title for a session file without parsing all of
+// it. Summary entries are written at the top of the file, so reading stops at
+// the first real user prompt.
+func readSessionTitle(sessionPath string) string {
+	file, err := os.Open(sessionPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 10*1024*1024)
+
+	var entries []LogEntry
+	for scanner.Scan() {
+		var entry LogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if err := parseMessageContent(&entry.Message); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+
+		if entry.Type == "user" && promptTitle(entry) != "" {
+			break
+		}
+	}
+
+	return getSessionTitle(entries)
+}
+
+// getSessionTitle returns the latest summary of a session, falling back to
+// its first real user prompt
+func getSessionTitle(entries []LogEntry) string {
+	summary := ""
+	for _, entry := range entries {
+		if entry.Type == "summary" && entry.Summary != "" {
+			summary = entry.Summary
+		}
+	}
+	if summary != "" {
+		return truncateTitle(summary)
+	}
+
+	for _, entry := range entries {
+		if entry.Type != "user" {
+			continue
+		}
+		if title := promptTitle(entry); title != "" {
+			return title
+		}
+	}
+	return ""
+}
+
+// promptTitle cleans up a user prompt for use as a title, returning an empty
+// string for tool results, commands and other generated messages
+func promptTitle(entry LogEntry) string {
+	if entry.IsMeta {
+		return ""
+	}
+
+	text := ""
+	switch content := entry.Message.Content.(type) {
+	case string:
+		text = content
+	case []ContentBlock:
+		for _, block := range content {
+			if textBlock, ok := block.(*TextBlock); ok {
+				text += textBlock.Text + " "
+			}
+		}
+	}
+
+	text = strings.TrimSpace(systemReminderPattern.ReplaceAllString(text, ""))
+	for _, prefix := range noisePromptPrefixes {
+		if strings.HasPrefix(text, prefix) {
+			return ""
+		}
+	}
+
+	return truncateTitle(strings.Join(strings.Fields(text), " "))
+}
+
+func truncateTitle(title string) string {
+	return truncateRunes(title, maxTitleLength)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"time"
+)
+
+// ToolCall pairs a tool_use block with the tool_result that answered it
+type ToolCall struct {
+	Use             *ToolUseBlock
+	Result          *ToolResultBlock
+	UseEntryUUID    string
+	ResultEntryUUID string
+	UseTime         time.Time
+	ResultTime      time.Time
+	GitBranch       string
+}
+
+// IsError reports whether the tool call's result was flagged as an error
+func (c ToolCall) IsError() bool {
+	return c.Result != nil && c.Result.IsError != nil && *c.Result.IsError
+}
+
+// ResultText returns the text content of the tool result
+func (c ToolCall) ResultText() string {
+	if c.Result == nil {
+		return ""
+	}
+	return toolResultText(c.Result.Content)
+}
+
+// pairToolCalls returns every tool call in a session in the order the tools
+// were invoked, with their results attached when present
+func pairToolCalls(entries []LogEntry) []ToolCall {
+	var calls []ToolCall
+	index := make(map[string]int)
+
+	for _, entry := range entries {
+		blocks, ok := entry.Message.Content.([]ContentBlock)
+		if !ok {
+			continue
+		}
+		for _, block := range blocks {
+			switch b := block.(type) {
+			case *ToolUseBlock:
+				// Assistant messages can be logged more than once; keep the first
+				if _, seen := index[b.Id]; seen {
+					continue
+				}
+				index[b.Id] = len(calls)
+				calls = append(calls, ToolCall{
+					Use:          b,
+					UseEntryUUID: entry.Uuid,
+					UseTime:      entry.Timestamp,
+					GitBranch:    entry.GitBranch,
+				})
+			case *ToolResultBlock:
+				if i, ok := index[b.ToolUseId]; ok && calls[i].Result == nil {
+					calls[i].Result = b
+					calls[i].ResultEntryUUID = entry.Uuid
+					calls[i].ResultTime = entry.Timestamp
+				}
+			}
+		}
+	}
+
+	return calls
+}
+
+// uniqueToolCalls pairs the tool calls of each session without the calls an
+// older session already made, so that totals across sessions count the calls
+// copied into resumed sessions once, as uniqueRecords does for responses
+func uniqueToolCalls(sessions []LoadedSession) [][]ToolCall {
+	unique := make([][]ToolCall, len(sessions))
+	seen := make(map[string]bool)
+	for _, i := range sessionsByAge(loadedSessionInfos(sessions)) {
+		for _, call := range pairToolCalls(sessions[i].Entries) {
+			if call.Use.Id != "" {
+				if seen[call.Use.Id] {
+					continue
+				}
+				seen[call.Use.Id] = true
+			}
+			unique[i] = append(unique[i], call)
+		}
+	}
+	return unique
+}
+
+// toolCallsByID indexes tool calls by their tool use ID
+func toolCallsByID(calls []ToolCall) map[string]ToolCall {
+	byID := make(map[string]ToolCall, len(calls))
+	for _, call := range calls {
+		byID[call.Use.Id] = call
+	}
+	return byID
+}
+
+// toolInputFilePath returns the file a tool call operated on, if any
+func toolInputFilePath(input ToolInput) string {
+	switch in := input.(type) {
+	case ReadInput:
+		return in.FilePath
+	case EditInput:
+		return in.FilePath
+	case MultiEditInput:
+		return in.FilePath
+	case WriteInput:
+		return in.FilePath
+	}
+	return ""
+}
+
+// toolResultText flattens tool result content, which is either a string or
+// a list of text blocks, into plain text
+func toolResultText(content interface{}) string {
+	if str, ok := content.(string); ok {
+		return str
+	}
+	text := ""
+	if items, ok := content.([]interface{}); ok {
+		for _, item := range items {
+			if block, ok := item.(map[string]interface{}); ok {
+				if t, ok := block["text"].(string); ok {
+					if text != "" {
+						text += "\n"
+					}
+					text += t
+				}
+			}
+		}
+	}
+	return text
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	maxErrorClusters      = 25
+	maxFailingInputs      = 25
+	errorTrendDays        = 30
+	maxErrorPatternLength = 160
+)
+
+// Error messages are clustered after replacing the parts that vary between
+// occurrences of the same problem
+var errorNormalizers = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`<[a-z_-]+>|</[a-z_-]+>`), ""},
+	{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), "<id>"},
+	{regexp.MustCompile(`(?:~|\.{0,2})?/[^\s:'"` + "`" + `,)]+`), "<path>"},
+	{regexp.MustCompile(`"[^"]*"|'[^']*'|` + "`[^`]*`"), "<str>"},
+	{regexp.MustCompile(`\b0x[0-9a-fA-F]+\b|\b\d+(\.\d+)?\b`), "<n>"},
+	{regexp.MustCompile(`\s+`), " "},
+}
+
+// ToolErrorRate counts calls and failures of one tool or project
+type ToolErrorRate struct {
+	Name   string
+	Calls  int
+	Errors int
+}
+
+// Rate returns the share of calls that failed
+func (r ToolErrorRate) Rate() float64 {
+	if r.Calls == 0 {
+		return 0
+	}
+	return float64(r.Errors) / float64(r.Calls)
+}
+
+// ToolErrorExample locates one failing tool call
+type ToolErrorExample struct {
+	Project ProjectInfo
+	Session SessionInfo
+	Call    ToolCall
+	Message string
+}
+
+// ErrorCluster groups error messages that only differ in paths, numbers
+// and quoted values
+type ErrorCluster struct {
+	Pattern string
+	Count   int
+	Tools   []string
+	Latest  ToolErrorExample
+}
+
+// FailingInput is a tool input that failed repeatedly, such as an Edit of
+// the same file or the same Bash command
+type FailingInput struct {
+	Tool     string
+	Input    string
+	Calls    int
+	Failures int
+	Latest   ToolErrorExample
+}
+
+// ToolErrorDay counts the tool calls and failures of one day
+type ToolErrorDay struct {
+	Day    time.Time
+	Calls  int
+	Errors int
+}
+
+// ToolErrorReport summarises tool failures across all sessions
+type ToolErrorReport struct {
+	Since    time.Time
+	Calls    int
+	Errors   int
+	Tools    []ToolErrorRate
+	Projects []ToolErrorRate
+	Days     []ToolErrorDay
+	Clusters []ErrorCluster
+	Inputs   []FailingInput
+}
+
+// Rate returns the share of all tool calls that failed
+func (r ToolErrorReport) Rate() float64 {
+	return ToolErrorRate{Calls: r.Calls, Errors: r.Errors}.Rate()
+}
+
+// getToolErrorReport analyses every tool call made since the given time,
+// or all of them when since is zero
+func getToolErrorReport(ctx context.Context, since, now time.Time) (ToolErrorReport, error) {
+	sessions, err := loadAllSessions(ctx)
+	if err != nil {
+		return ToolErrorReport{}, err
+	}
+	return buildToolErrorReport(sessions, since, now), nil
+}
+
+func buildToolErrorReport(sessions []LoadedSession, since, now time.Time) ToolErrorReport {
+	report := ToolErrorReport{Since: since}
+
+	tools := make(map[string]*ToolErrorRate)
+	projects := make(map[string]*ToolErrorRate)
+	clusters := make(map[string]*ErrorCluster)
+	clusterTools := make(map[string]map[string]bool)
+	inputs := make(map[string]*FailingInput)
+
+	today := startOfDay(now)
+	dayIndex := make(map[time.Time]int)
+	for day := today.AddDate(0, 0, 1-errorTrendDays); !day.After(today); day = day.AddDate(0, 0, 1) {
+		dayIndex[day] = len(report.Days)
+		report.Days = append(report.Days, ToolErrorDay{Day: day})
+	}
+
+	for s, calls := range uniqueToolCalls(sessions) {
+		session := sessions[s]
+		for _, call := range calls {
+			if call.Result == nil || (!since.IsZero() && call.UseTime.Before(since)) {
+				continue
+			}
+			failed := call.IsError()
+
+			report.Calls++
+			tool := countRate(tools, call.Use.Name)
+			project := countRate(projects, session.Project.DisplayName)
+			tool.Calls++
+			project.Calls++
+
+			if i, ok := dayIndex[startOfDay(call.UseTime.In(now.Location()))]; ok {
+				report.Days[i].Calls++
+				if failed {
+					report.Days[i].Errors++
+				}
+			}
+
+			inputKey := failingInputKey(call)
+			var input *FailingInput
+			if inputKey != "" {
+				key := call.Use.Name + "\x00" + inputKey
+				input = inputs[key]
+				if input == nil {
+					input = &FailingInput{Tool: call.Use.Name, Input: inputKey}
+					inputs[key] = input
+				}
+				input.Calls++
+			}
+
+			if !failed {
+				continue
+			}
+			report.Errors++
+			tool.Errors++
+			project.Errors++
+
+			message := strings.TrimSpace(call.ResultText())
+			example := ToolErrorExample{Project: session.Project, Session: session.Session, Call: call, Message: message}
+
+			pattern := normalizeErrorMessage(message)
+			cluster := clusters[pattern]
+			if cluster == nil {
+				cluster = &ErrorCluster{Pattern: pattern}
+				clusters[pattern] = cluster
+				clusterTools[pattern] = make(map[string]bool)
+			}
+			cluster.Count++
+			clusterTools[pattern][call.Use.Name] = true
+			if call.UseTime.After(cluster.Latest.Call.UseTime) {
+				cluster.Latest = example
+			}
+
+			if input != nil {
+				input.Failures++
+				if call.UseTime.After(input.Latest.Call.UseTime) {
+					input.Latest = example
+				}
+			}
+		}
+	}
+
+	report.Tools = sortedRates(tools)
+	report.Projects = sortedRates(projects)
+
+	for pattern, cluster := range clusters {
+		for tool := range clusterTools[pattern] {
+			cluster.Tools = append(cluster.Tools, tool)
+		}
+		sort.Strings(cluster.Tools)
+		report.Clusters = append(report.Clusters, *cluster)
+	}
+	sort.Slice(report.Clusters, func(i, j int) bool {
+		if report.Clusters[i].Count != report.Clusters[j].Count {
+			return report.Clusters[i].Count > report.Clusters[j].Count
+		}
+		return report.Clusters[i].Pattern < report.Clusters[j].Pattern
+	})
+	if len(report.Clusters) > maxErrorClusters {
+		report.Clusters = report.Clusters[:maxErrorClusters]
+	}
+
+	for _, input := range inputs {
+		if input.Failures > 0 {
+			report.Inputs = append(report.Inputs, *input)
+		}
+	}
+	sort.Slice(report.Inputs, func(i, j int) bool {
+		if report.Inputs[i].Failures != report.Inputs[j].Failures {
+			return report.Inputs[i].Failures > report.Inputs[j].Failures
+		}
+		return report.Inputs[i].Input < report.Inputs[j].Input
+	})
+	if len(report.Inputs) > maxFailingInputs {
+		report.Inputs = report.Inputs[:maxFailingInputs]
+	}
+
+	return report
+}
+
+func countRate(rates map[string]*ToolErrorRate, name string) *ToolErrorRate {
+	rate := rates[name]
+	if rate == nil {
+		rate = &ToolErrorRate{Name: name}
+		rates[name] = rate
+	}
+	return rate
+}
+
+// sortedRates orders rates by number of errors, then by error rate
+func sortedRates(rates map[string]*ToolErrorRate) []ToolErrorRate {
+	var sorted []ToolErrorRate
+	for _, rate := range rates {
+		sorted = append(sorted, *rate)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Errors != sorted[j].Errors {
+			return sorted[i].Errors > sorted[j].Errors
+		}
+		if sorted[i].Rate() != sorted[j].Rate() {
+			return sorted[i].Rate() > sorted[j].Rate()
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// normalizeErrorMessage reduces an error to its first line with paths,
+// identifiers, numbers and quoted values replaced by placeholders
+func normalizeErrorMessage(message string) string {
+	message = systemReminderPattern.ReplaceAllString(message, "")
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(line)
+		for _, normalizer := range errorNormalizers {
+			line = normalizer.pattern.ReplaceAllString(line, normalizer.replacement)
+		}
+		line = strings.TrimSpace(line)
+		if line != "" {
+			return truncateRunes(line, maxErrorPatternLength)
+		}
+	}
+	return "(empty error)"
+}
+
+// failingInputKey identifies the input of a tool call for grouping repeated
+// failures: the file for file tools, the command for Bash and the pattern
+// for searches
+func failingInputKey(call ToolCall) string {
+	if filePath := toolInputFilePath(call.Use.Input); filePath != "" {
+		return filePath
+	}
+	switch in := call.Use.Input.(type) {
+	case BashInput:
+		return truncateRunes(singleLine(in.Command), maxErrorPatternLength)
+	case GrepInput:
+		return in.Pattern
+	case GlobInput:
+		return in.Pattern
+	}
+	return ""
+}
+
+// toolErrorChart draws the successful and failed tool calls of each day
+func toolErrorChart(report ToolErrorReport) BarChart {
+	labels := make([]string, len(report.Days))
+	values := make([][]float64, len(report.Days))
+	for i, day := range report.Days {
+		labels[i] = day.Day.Format("Jan 2")
+		values[i] = []float64{float64(day.Calls - day.Errors), float64(day.Errors)}
+	}
+	return newBarChart("Tool calls, last 30 days", []ChartSeries{
+		{Name: "Succeeded", Color: "#10b981"},
+		{Name: "Failed", Color: "#dc2626"},
+	}, labels, values, formatChartNumber)
+}
+
+// formatPercent formats a ratio as a percentage
+func formatPercent(ratio float64) string {
+	return fmt.Sprintf("%.1f%%", ratio*100)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// toolCallEntries returns a tool call made at t and its result
+func toolCallEntries(id, tool string, t time.Time, failed bool) []LogEntry {
+	return []LogEntry{
+		{
+			Type:      "assistant",
+			Timestamp: t,
+			Message:   Message{Role: "assistant", Content: []ContentBlock{&ToolUseBlock{Type: "tool_use", Id: id, Name: tool}}},
+		},
+		{
+			Type:      "user",
+			Timestamp: t.Add(time.Second),
+			Message:   Message{Role: "user", Content: []ContentBlock{&ToolResultBlock{Type: "tool_result", ToolUseId: id, Content: "done", IsError: &failed}}},
+		},
+	}
+}
+
+func TestToolErrorTrendDays(t *testing.T) {
+	// Clocks in New York went forward on Sunday, March 8 2026, so that day
+	// was 23 hours long
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+	date := func(day, hour int) time.Time { return time.Date(2026, 3, day, hour, 0, 0, 0, newYork) }
+	now := date(20, 12)
+
+	tests := []struct {
+		name       string
+		at         time.Time
+		failed     bool
+		day        time.Time
+		wantCalls  int
+		wantErrors int
+	}{
+		{"before the clocks changed", date(7, 10), true, date(7, 0), 1, 1},
+		{"on the day the clocks changed", date(8, 23), false, date(8, 0), 1, 0},
+		{"the day after the change", date(9, 0), true, date(9, 0), 1, 1},
+		{"days after the change", date(12, 9), true, date(12, 0), 1, 1},
+		{"late evening, the next day in UTC", date(13, 23), false, date(13, 0), 1, 0},
+		{"today", date(20, 8), true, date(20, 0), 1, 1},
+	}
+
+	var entries []LogEntry
+	for i, tt := range tests {
+		entries = append(entries, toolCallEntries(string(rune('a'+i)), "Bash", tt.at.UTC(), tt.failed)...)
+	}
+	report := buildToolErrorReport([]LoadedSession{{Entries: entries}}, time.Time{}, now)
+
+	if len(report.Days) != errorTrendDays {
+		t.Fatalf("trend has %d days, want %d", len(report.Days), errorTrendDays)
+	}
+	for i := 1; i < len(report.Days); i++ {
+		if want := report.Days[i-1].Day.AddDate(0, 0, 1); !report.Days[i].Day.Equal(want) {
+			t.Fatalf("day %d is %s, want %s", i, report.Days[i].Day, want)
+		}
+	}
+	days := make(map[time.Time]ToolErrorDay)
+	for _, day := range report.Days {
+		days[day.Day] = day
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := days[tt.day]
+			if !ok {
+				t.Fatalf("no trend entry for %s", tt.day.Format("Jan 2"))
+			}
+			if got.Calls != tt.wantCalls || got.Errors != tt.wantErrors {
+				t.Errorf("%s has %d calls and %d errors, want %d and %d",
+					tt.day.Format("Jan 2"), got.Calls, got.Errors, tt.wantCalls, tt.wantErrors)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// forEachParallel calls fn for every index in [0, n) using at most workers
+// goroutines. No new work is started once ctx is cancelled, in which case the
+// context error is returned after in-flight calls finish.
+func forEachParallel(ctx context.Context, workers, n int, fn func(i int)) error {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+
+dispatch:
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		// A cancelled context and an idle worker can both be ready, and
+		// select picks between them at random, so cancellation is checked
+		// before each send as well
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return ctx.Err()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestForEachParallelVisitsEveryIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		n       int
+	}{
+		{"no work", 4, 0},
+		{"single worker", 1, 10},
+		{"more workers than work", 8, 3},
+		{"no workers given", 0, 5},
+		{"many items", 4, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			seen := make(map[int]int)
+			err := forEachParallel(context.Background(), tt.workers, tt.n, func(i int) {
+				mu.Lock()
+				seen[i]++
+				mu.Unlock()
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(seen) != tt.n {
+				t.Fatalf("visited %d indexes, want %d", len(seen), tt.n)
+			}
+			for i := 0; i < tt.n; i++ {
+				if seen[i] != 1 {
+					t.Errorf("index %d visited %d times", i, seen[i])
+				}
+			}
+		})
+	}
+}
+
+func TestForEachParallelCancellation(t *testing.T) {
+	tests := []struct {
+		name     string
+		workers  int
+		n        int
+		cancelAt int // index whose call cancels the context, -1 to cancel up front
+		maxCalls int
+	}{
+		{"cancelled before start", 4, 100, -1, 0},
+		{"cancelled by the first call", 1, 100, 0, 2},
+		{"cancelled part way", 1, 100, 10, 12},
+		{"cancelled with several workers", 4, 1000, 10, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelAt < 0 {
+				cancel()
+			}
+
+			var mu sync.Mutex
+			calls := 0
+			err := forEachParallel(ctx, tt.workers, tt.n, func(i int) {
+				mu.Lock()
+				calls++
+				mu.Unlock()
+				if i == tt.cancelAt {
+					cancel()
+				}
+			})
+			if err != context.Canceled {
+				t.Errorf("error = %v, want %v", err, context.Canceled)
+			}
+			if calls > tt.maxCalls {
+				t.Errorf("%d calls after cancellation, want at most %d", calls, tt.maxCalls)
+			}
+		})
+	}
+}